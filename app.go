@@ -2,6 +2,7 @@ package arukas
 
 import (
 	"encoding/json"
+	"errors"
 	"time"
 )
 
@@ -44,6 +45,18 @@ func (a *App) ServiceID() string {
 	return a.Relationships.Services.Data[0].ID
 }
 
+// ServiceIDs returns all service ids
+func (a *App) ServiceIDs() []string {
+	if a.Relationships == nil || a.Relationships.Services == nil {
+		return nil
+	}
+	var ids []string
+	for _, s := range a.Relationships.Services.Data {
+		ids = append(ids, s.ID)
+	}
+	return ids
+}
+
 // AppAttr represents app.attributes object
 type AppAttr struct {
 	Name      string     `json:"name,omitempty"`
@@ -51,6 +64,20 @@ type AppAttr struct {
 	UpdatedAt *time.Time `json:"updated-at,omitempty"`
 }
 
+// AppPatch represents parameter to update app attributes.
+// Fields with nil value are left unchanged.
+type AppPatch struct {
+	Name *string
+}
+
+// Validate returns error if specified fields are invalid
+func (p AppPatch) Validate() error {
+	if p.Name == nil {
+		return errors.New("AppPatch has no fields to update")
+	}
+	return validateAppName("Name", *p.Name)
+}
+
 // AppData represents data object(included app and child service)
 type AppData struct {
 	Data     *App          `json:"data"`
@@ -87,6 +114,11 @@ func (a *AppData) ServiceID() string {
 	return a.Data.ServiceID()
 }
 
+// ServiceIDs returns all service ids
+func (a *AppData) ServiceIDs() []string {
+	return a.Data.ServiceIDs()
+}
+
 // Service returns service(from included)
 func (a *AppData) Service() *Service {
 	for _, v := range a.Included {
@@ -103,3 +135,24 @@ func (a *AppData) Service() *Service {
 	}
 	return nil
 }
+
+// Services returns all services(from included)
+func (a *AppData) Services() []*Service {
+	var services []*Service
+	for _, v := range a.Included {
+		var service Service
+		data, err := json.Marshal(v)
+		if err != nil {
+			continue
+		}
+		if err := json.Unmarshal(data, &service); err != nil {
+			continue
+		}
+		if service.Type != "" && service.Type != TypeServices {
+			continue
+		}
+
+		services = append(services, &service)
+	}
+	return services
+}
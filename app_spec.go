@@ -0,0 +1,147 @@
+package arukas
+
+import (
+	"github.com/hashicorp/go-multierror"
+)
+
+// AppSpec represents parameter to create app with multiple services
+type AppSpec struct {
+	Name     string
+	Services []ServiceSpec
+}
+
+// ServiceSpec represents parameter of a service in AppSpec
+type ServiceSpec struct {
+	Command       string
+	CustomDomains []string
+	Image         string
+	Instances     int32
+	Ports         Ports
+	Environment   []*Env
+	SubDomain     string
+	Region        string
+	Plan          string
+}
+
+// ToAppSpec returns *AppSpec which has single service built from RequestParam
+func (p *RequestParam) ToAppSpec() *AppSpec {
+	return &AppSpec{
+		Name: p.Name,
+		Services: []ServiceSpec{
+			{
+				Command:       p.Command,
+				CustomDomains: p.CustomDomains,
+				Image:         p.Image,
+				Instances:     p.Instances,
+				Ports:         p.Ports,
+				Environment:   p.Environment,
+				SubDomain:     p.SubDomain,
+				Region:        p.Region,
+				Plan:          p.Plan,
+			},
+		},
+	}
+}
+
+// requestParam returns *RequestParam built from ServiceSpec
+func (s *ServiceSpec) requestParam(name string) *RequestParam {
+	return &RequestParam{
+		Name:          name,
+		Command:       s.Command,
+		CustomDomains: s.CustomDomains,
+		Image:         s.Image,
+		Instances:     s.Instances,
+		Ports:         s.Ports,
+		Environment:   s.Environment,
+		SubDomain:     s.SubDomain,
+		Region:        s.Region,
+		Plan:          s.Plan,
+	}
+}
+
+// ValidateForCreate returns error if spec is invalid
+func (s *AppSpec) ValidateForCreate() error {
+	var results error
+
+	if err := validateAppName("Name", s.Name); err != nil {
+		results = multierror.Append(results, err)
+	}
+	if err := validateRequired("Services", len(s.Services)); err != nil {
+		results = multierror.Append(results, err)
+	}
+
+	subDomains := map[string]bool{}
+	for i := range s.Services {
+		service := &s.Services[i]
+		prefix := fieldPath("Services", i, "")
+
+		if err := service.requestParam(s.Name).ValidateForCreate(); err != nil {
+			for _, e := range flattenErrors(err) {
+				ve, ok := e.(*ValidationError)
+				if !ok {
+					results = multierror.Append(results, e)
+					continue
+				}
+				if ve.Field == "Name" {
+					continue // already validated
+				}
+				ve.Field = prefix + "." + ve.Field
+				results = multierror.Append(results, ve)
+			}
+		}
+
+		if service.SubDomain != "" {
+			if subDomains[service.SubDomain] {
+				results = multierror.Append(results, duplicatedError(prefix+".SubDomain"))
+			}
+			subDomains[service.SubDomain] = true
+		}
+	}
+
+	return results
+}
+
+// ToAppData returns *AppData built from AppSpec.
+// Each service has distinct link ID(1, 2, ...) and is linked from the app.
+func (s *AppSpec) ToAppData() *AppData {
+	if err := s.ValidateForCreate(); err != nil {
+		return nil
+	}
+
+	linkIDs := make([]int32, 0, len(s.Services))
+	included := make([]interface{}, 0, len(s.Services))
+	for i, service := range s.Services {
+		linkID := int32(i + LinkID)
+		region := service.Region
+		if region == "" {
+			region = RegionJPTokyo
+		}
+
+		linkIDs = append(linkIDs, linkID)
+		included = append(included, &Service{
+			Type:   TypeServices,
+			LinkID: linkID,
+			Attributes: &ServiceAttr{
+				Command:       service.Command,
+				CustomDomains: CustomDomains(service.CustomDomains...),
+				Image:         service.Image,
+				Instances:     service.Instances,
+				Ports:         service.Ports,
+				Environment:   service.Environment,
+				SubDomain:     service.SubDomain,
+			},
+			Relationships: NewServiceRelationship(region, service.Plan),
+		})
+	}
+
+	return &AppData{
+		Data: &App{
+			Type: TypeApps,
+			Attributes: &AppAttr{
+				Name: s.Name,
+			},
+			Relationships: NewAppRelationship(linkIDs...),
+		},
+		Included: included,
+	}
+}
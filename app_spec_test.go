@@ -0,0 +1,123 @@
+package arukas
+
+import (
+	"errors"
+	"sort"
+	"testing"
+
+	"github.com/hashicorp/go-multierror"
+	"github.com/stretchr/testify/assert"
+)
+
+var validAppSpec = &AppSpec{
+	Name: "foobar",
+	Services: []ServiceSpec{
+		{
+			Image:     "nginx:latest",
+			Instances: 1,
+			Ports:     Ports{{Protocol: "tcp", Number: 80}},
+			Plan:      PlanFree,
+		},
+		{
+			Image:     "redis:latest",
+			Instances: 2,
+			Ports:     Ports{{Protocol: "tcp", Number: 6379}},
+			Plan:      PlanHobby,
+		},
+	},
+}
+
+func TestAppSpec_ValidateForCreate(t *testing.T) {
+
+	expects := []struct {
+		scenario string
+		fields   []string
+		spec     *AppSpec
+	}{
+		{
+			scenario: "Required values are empty",
+			fields:   []string{"Name", "Services"},
+			spec:     &AppSpec{},
+		},
+		{
+			scenario: "Valid services",
+			spec:     validAppSpec,
+		},
+		{
+			scenario: "Invalid service",
+			fields:   []string{"Services[1].Image", "Services[1].Plan"},
+			spec: &AppSpec{
+				Name: "foobar",
+				Services: []ServiceSpec{
+					validAppSpec.Services[0],
+					{Instances: 1, Ports: Ports{{Protocol: "tcp", Number: 80}}},
+				},
+			},
+		},
+		{
+			scenario: "Duplicated subdomain",
+			fields:   []string{"Services[1].SubDomain"},
+			spec: &AppSpec{
+				Name: "foobar",
+				Services: []ServiceSpec{
+					{Image: "nginx", Instances: 1, Ports: Ports{{Protocol: "tcp", Number: 80}}, Plan: PlanHobby, SubDomain: "foo"},
+					{Image: "nginx", Instances: 1, Ports: Ports{{Protocol: "tcp", Number: 80}}, Plan: PlanHobby, SubDomain: "foo"},
+				},
+			},
+		},
+	}
+
+	for _, expect := range expects {
+		t.Run(expect.scenario, func(t *testing.T) {
+			var fields []string
+			for _, e := range ValidationErrors(expect.spec.ValidateForCreate()) {
+				fields = append(fields, e.Field)
+			}
+			sort.Strings(fields)
+			assert.Equal(t, expect.fields, fields)
+		})
+	}
+}
+
+func TestValidationErrors(t *testing.T) {
+	other := errors.New("other")
+	err := multierror.Append(
+		requiredError("Name"),
+		other,
+		multierror.Append(nil, requiredError("Image"), errors.New("nested")),
+	)
+
+	errs := ValidationErrors(err)
+	assert.Len(t, errs, 2)
+	assert.Equal(t, "Name", errs[0].Field)
+	assert.Equal(t, "Image", errs[1].Field)
+
+	assert.Len(t, flattenErrors(err), 4)
+	assert.Equal(t, other, flattenErrors(err)[1])
+	assert.Empty(t, ValidationErrors(other))
+	assert.Empty(t, ValidationErrors(nil))
+}
+
+func TestAppSpec_ToAppData(t *testing.T) {
+	data := validAppSpec.ToAppData()
+	assert.NotNil(t, data)
+
+	links := data.Data.Relationships.Services.Data
+	assert.Len(t, links, 2)
+	assert.Equal(t, int32(1), links[0].LinkID)
+	assert.Equal(t, int32(2), links[1].LinkID)
+
+	services := data.Services()
+	assert.Len(t, services, 2)
+	for i, s := range services {
+		assert.Equal(t, links[i].LinkID, s.LinkID)
+		assert.Equal(t, validAppSpec.Services[i].Image, s.Image())
+	}
+	assert.Equal(t, PlanID(RegionJPTokyo, PlanHobby), services[1].PlanID())
+}
+
+func TestRequestParam_ToAppSpec(t *testing.T) {
+	spec := validCreateAppParam.ToAppSpec()
+	assert.NoError(t, spec.ValidateForCreate())
+	assert.Equal(t, validCreateAppParam.ToAppData(), spec.ToAppData())
+}
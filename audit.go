@@ -0,0 +1,380 @@
+package arukas
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"os"
+	"sync"
+	"time"
+)
+
+// Outcomes of AuditRecord
+const (
+	AuditSuccess = "success"
+	AuditFailure = "failure"
+)
+
+const redactedValue = "[REDACTED]"
+
+// AuditRecord represents a mutation performed through AuditClient
+type AuditRecord struct {
+	Time      time.Time `json:"time"`
+	Operation string    `json:"operation"`
+	// TargetID is ID of the app or the service. ID of created app for CreateApp/CreateAppWithSpec
+	TargetID string `json:"target-id,omitempty"`
+	// Request is redacted parameter of the operation.
+	// Only environment values are redacted, Command is recorded as is.
+	Request interface{} `json:"request,omitempty"`
+	// Before and After are redacted service read before/after the operation
+	Before  *Service `json:"before,omitempty"`
+	After   *Service `json:"after,omitempty"`
+	Outcome string   `json:"outcome"`
+	Error   string   `json:"error,omitempty"`
+	// DurationMS is duration of the mutation API call, reads of Before/After are not included
+	DurationMS int64             `json:"duration-ms"`
+	Caller     map[string]string `json:"caller,omitempty"`
+}
+
+// AuditSink receives audit records
+type AuditSink interface {
+	WriteAuditRecord(r *AuditRecord) error
+}
+
+// AuditSinkFunc is an AuditSink which calls the function
+type AuditSinkFunc func(r *AuditRecord) error
+
+// WriteAuditRecord implements AuditSink interface
+func (f AuditSinkFunc) WriteAuditRecord(r *AuditRecord) error {
+	return f(r)
+}
+
+// AuditWriterSink writes a JSON record per line
+type AuditWriterSink struct {
+	mu sync.Mutex
+	w  io.Writer
+}
+
+// NewAuditWriterSink returns *AuditWriterSink which writes to w
+func NewAuditWriterSink(w io.Writer) *AuditWriterSink {
+	return &AuditWriterSink{w: w}
+}
+
+// WriteAuditRecord implements AuditSink interface
+func (s *AuditWriterSink) WriteAuditRecord(r *AuditRecord) error {
+	data, err := json.Marshal(r)
+	if err != nil {
+		return err
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	_, err = s.w.Write(append(data, '\n'))
+	return err
+}
+
+// AuditFileSink appends JSON records to a file
+type AuditFileSink struct {
+	*AuditWriterSink
+	file *os.File
+}
+
+// NewAuditFileSink opens the file in append mode and returns *AuditFileSink
+func NewAuditFileSink(path string) (*AuditFileSink, error) {
+	f, err := os.OpenFile(path, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0600)
+	if err != nil {
+		return nil, err
+	}
+	return &AuditFileSink{AuditWriterSink: NewAuditWriterSink(f), file: f}, nil
+}
+
+// Close closes the file
+func (s *AuditFileSink) Close() error {
+	return s.file.Close()
+}
+
+type auditCallerKey struct{}
+
+// WithAuditCaller returns a context which has caller metadata recorded in AuditRecord.Caller.
+// Metadata already in ctx is merged, caller takes precedence.
+func WithAuditCaller(ctx context.Context, caller map[string]string) context.Context {
+	merged := map[string]string{}
+	for k, v := range AuditCallerFromContext(ctx) {
+		merged[k] = v
+	}
+	for k, v := range caller {
+		merged[k] = v
+	}
+	return context.WithValue(ctx, auditCallerKey{}, merged)
+}
+
+// AuditCallerFromContext returns caller metadata set by WithAuditCaller
+func AuditCallerFromContext(ctx context.Context) map[string]string {
+	if ctx == nil {
+		return nil
+	}
+	caller, _ := ctx.Value(auditCallerKey{}).(map[string]string)
+	return caller
+}
+
+// AuditParam represents parameters of AuditClient
+type AuditParam struct {
+	Sink AuditSink
+	// RedactKeys are substrings of environment variable keys whose values are redacted, case-insensitive.
+	// If empty, default keys such as "PASSWORD" and "SECRET" are used.
+	// Command is not redacted, don't pass secrets in command line arguments.
+	RedactKeys []string
+	// OnSinkError is called when Sink returns error. The mutation itself is not affected.
+	OnSinkError func(r *AuditRecord, err error)
+}
+
+// AuditClient records mutations to AuditSink. Reads are not recorded.
+//
+// Caller metadata is taken from the context of UpdateApp/PatchService,
+// or the context bound by WithContext for other mutations.
+type AuditClient struct {
+	Client
+	param AuditParam
+	ctx   context.Context
+}
+
+// NewAuditClient returns a new AuditClient wrapping c
+func NewAuditClient(c Client, p *AuditParam) (*AuditClient, error) {
+	if p == nil || p.Sink == nil {
+		return nil, errors.New("AuditParam.Sink is required")
+	}
+	param := *p
+	if len(param.RedactKeys) == 0 {
+		param.RedactKeys = secretEnvKeys
+	}
+	return &AuditClient{Client: c, param: param, ctx: context.Background()}, nil
+}
+
+// WithContext returns a shallow copy of c which takes caller metadata from ctx
+func (c *AuditClient) WithContext(ctx context.Context) *AuditClient {
+	res := *c
+	res.ctx = ctx
+	return &res
+}
+
+// auditOperation represents an operation being recorded
+type auditOperation struct {
+	client *AuditClient
+	record *AuditRecord
+}
+
+func (c *AuditClient) begin(ctx context.Context, operation, targetID string, request interface{}) *auditOperation {
+	return &auditOperation{
+		client: c,
+		record: &AuditRecord{
+			Time:      time.Now(),
+			Operation: operation,
+			TargetID:  targetID,
+			Request:   request,
+			Caller:    AuditCallerFromContext(ctx),
+		},
+	}
+}
+
+// call calls f and records its duration
+func (o *auditOperation) call(f func() error) error {
+	start := time.Now()
+	err := f()
+	o.record.DurationMS = int64(time.Since(start) / time.Millisecond)
+	return err
+}
+
+func (o *auditOperation) end(err error) {
+	r := o.record
+	r.Outcome = AuditSuccess
+	if err != nil {
+		r.Outcome, r.Error = AuditFailure, err.Error()
+	}
+	if err := o.client.param.Sink.WriteAuditRecord(r); err != nil && o.client.param.OnSinkError != nil {
+		o.client.param.OnSinkError(r, err)
+	}
+}
+
+// readService returns redacted service, returns nil if failed
+func (c *AuditClient) readService(id string) *Service {
+	s, err := c.Client.ReadService(id)
+	if err != nil {
+		return nil
+	}
+	return c.redactService(s.Data)
+}
+
+// readAppService returns redacted first service of the app, returns nil if failed
+func (c *AuditClient) readAppService(id string) *Service {
+	app, err := c.Client.ReadApp(id)
+	if err != nil {
+		return nil
+	}
+	return c.redactService(app.Service())
+}
+
+func (c *AuditClient) redactEnv(envs []*Env) []*Env {
+	if envs == nil {
+		return nil
+	}
+	res := make([]*Env, 0, len(envs))
+	for _, env := range envs {
+		if env == nil {
+			continue
+		}
+		redacted := *env
+		if isSecretEnvKey(env.Key, c.param.RedactKeys) {
+			redacted.Value = redactedValue
+		}
+		res = append(res, &redacted)
+	}
+	return res
+}
+
+func (c *AuditClient) redactService(s *Service) *Service {
+	if s == nil || s.Attributes == nil {
+		return s
+	}
+	res := *s
+	attr := *s.Attributes
+	attr.Environment = c.redactEnv(attr.Environment)
+	res.Attributes = &attr
+	return &res
+}
+
+func (c *AuditClient) redactParam(p *RequestParam) *RequestParam {
+	if p == nil {
+		return nil
+	}
+	res := *p
+	res.Environment = c.redactEnv(p.Environment)
+	return &res
+}
+
+func (c *AuditClient) redactSpec(spec *AppSpec) *AppSpec {
+	if spec == nil {
+		return nil
+	}
+	res := *spec
+	res.Services = make([]ServiceSpec, len(spec.Services))
+	for i, s := range spec.Services {
+		s.Environment = c.redactEnv(s.Environment)
+		res.Services[i] = s
+	}
+	return &res
+}
+
+func (c *AuditClient) redactPatch(patch *ServicePatch) *ServicePatch {
+	if patch == nil {
+		return nil
+	}
+	res := *patch
+	res.Environment = c.redactEnv(patch.Environment)
+	return &res
+}
+
+// CreateApp creates the app and records it
+func (c *AuditClient) CreateApp(param *RequestParam) (*AppData, error) {
+	op := c.begin(c.ctx, "CreateApp", "", c.redactParam(param))
+	var app *AppData
+	err := op.call(func() (err error) {
+		app, err = c.Client.CreateApp(param)
+		return err
+	})
+	if err == nil && app.Data != nil {
+		op.record.TargetID = app.Data.ID
+		op.record.After = c.redactService(app.Service())
+	}
+	op.end(err)
+	return app, err
+}
+
+// CreateAppWithSpec creates the app and records it
+func (c *AuditClient) CreateAppWithSpec(spec *AppSpec) (*AppData, error) {
+	op := c.begin(c.ctx, "CreateAppWithSpec", "", c.redactSpec(spec))
+	var app *AppData
+	err := op.call(func() (err error) {
+		app, err = c.Client.CreateAppWithSpec(spec)
+		return err
+	})
+	if err == nil && app.Data != nil {
+		op.record.TargetID = app.Data.ID
+		op.record.After = c.redactService(app.Service())
+	}
+	op.end(err)
+	return app, err
+}
+
+// UpdateApp updates the app and records it
+func (c *AuditClient) UpdateApp(ctx context.Context, id string, patch AppPatch) (*AppData, error) {
+	op := c.begin(ctx, "UpdateApp", id, patch)
+	var app *AppData
+	err := op.call(func() (err error) {
+		app, err = c.Client.UpdateApp(ctx, id, patch)
+		return err
+	})
+	op.end(err)
+	return app, err
+}
+
+// DeleteApp deletes the app and records it
+func (c *AuditClient) DeleteApp(id string) error {
+	op := c.begin(c.ctx, "DeleteApp", id, nil)
+	op.record.Before = c.readAppService(id)
+	err := op.call(func() error { return c.Client.DeleteApp(id) })
+	op.end(err)
+	return err
+}
+
+// UpdateService updates the service and records it
+func (c *AuditClient) UpdateService(id string, param *RequestParam) (*ServiceData, error) {
+	op := c.begin(c.ctx, "UpdateService", id, c.redactParam(param))
+	op.record.Before = c.readService(id)
+	var s *ServiceData
+	err := op.call(func() (err error) {
+		s, err = c.Client.UpdateService(id, param)
+		return err
+	})
+	if err == nil {
+		op.record.After = c.readService(id)
+	}
+	op.end(err)
+	return s, err
+}
+
+// PatchService patches the service and records it
+func (c *AuditClient) PatchService(ctx context.Context, id string, patch *ServicePatch) (*ServiceData, error) {
+	op := c.begin(ctx, "PatchService", id, c.redactPatch(patch))
+	op.record.Before = c.readService(id)
+	var s *ServiceData
+	err := op.call(func() (err error) {
+		s, err = c.Client.PatchService(ctx, id, patch)
+		return err
+	})
+	if err == nil {
+		op.record.After = c.readService(id)
+	}
+	op.end(err)
+	return s, err
+}
+
+// PowerOn powers on the service and records it
+func (c *AuditClient) PowerOn(id string) error {
+	return c.power("PowerOn", id, c.Client.PowerOn)
+}
+
+// PowerOff powers off the service and records it
+func (c *AuditClient) PowerOff(id string) error {
+	return c.power("PowerOff", id, c.Client.PowerOff)
+}
+
+func (c *AuditClient) power(operation, id string, f func(id string) error) error {
+	op := c.begin(c.ctx, operation, id, nil)
+	op.record.Before = c.readService(id)
+	err := op.call(func() error { return f(id) })
+	if err == nil {
+		op.record.After = c.readService(id)
+	}
+	op.end(err)
+	return err
+}
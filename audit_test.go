@@ -0,0 +1,181 @@
+package arukas
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestAuditClient(t *testing.T) {
+	ctx := WithAuditCaller(context.Background(), map[string]string{"user": "alice"})
+	base := newTestClient()
+	base.addApp(testAppID, testServiceID, "foo", &ServiceAttr{
+		Image:       "nginx",
+		Instances:   1,
+		Environment: []*Env{{Key: "DB_PASSWORD", Value: "secret"}, {Key: "MODE", Value: "prod"}},
+	})
+
+	var records []*AuditRecord
+	c, err := NewAuditClient(base, &AuditParam{Sink: AuditSinkFunc(func(r *AuditRecord) error {
+		records = append(records, r)
+		return nil
+	})})
+	require.NoError(t, err)
+
+	t.Run("reads are not recorded", func(t *testing.T) {
+		records = nil
+		_, err := c.ReadService(testServiceID)
+		assert.NoError(t, err)
+		assert.Empty(t, records)
+	})
+
+	t.Run("PatchService records before/after with redaction", func(t *testing.T) {
+		records = nil
+		_, err := c.PatchService(ctx, testServiceID, &ServicePatch{
+			Instances:   Int32(2),
+			Environment: []*Env{{Key: "API_TOKEN", Value: "xxx"}},
+		})
+		require.NoError(t, err)
+		require.Len(t, records, 1)
+
+		r := records[0]
+		assert.Equal(t, "PatchService", r.Operation)
+		assert.Equal(t, testServiceID, r.TargetID)
+		assert.Equal(t, AuditSuccess, r.Outcome)
+		assert.Equal(t, map[string]string{"user": "alice"}, r.Caller)
+		assert.Equal(t, redactedValue, r.Request.(*ServicePatch).Environment[0].Value)
+		assert.Equal(t, int32(1), r.Before.Attributes.Instances)
+		assert.Equal(t, int32(2), r.After.Attributes.Instances)
+		for _, env := range r.Before.Attributes.Environment {
+			if env.Key == "DB_PASSWORD" {
+				assert.Equal(t, redactedValue, env.Value)
+			}
+		}
+
+		// redaction must not modify the real service
+		s, err := base.ReadService(testServiceID)
+		require.NoError(t, err)
+		assert.Equal(t, "xxx", s.Data.Attributes.Environment[0].Value)
+	})
+
+	t.Run("failure", func(t *testing.T) {
+		records = nil
+		base.errors["PowerOn"] = errors.New("boom")
+		defer delete(base.errors, "PowerOn")
+
+		err := c.WithContext(ctx).PowerOn(testServiceID)
+		assert.Error(t, err)
+		require.Len(t, records, 1)
+		assert.Equal(t, AuditFailure, records[0].Outcome)
+		assert.Equal(t, "boom", records[0].Error)
+		assert.Equal(t, "alice", records[0].Caller["user"])
+		assert.NotNil(t, records[0].Before)
+		assert.Nil(t, records[0].After)
+	})
+
+	t.Run("CreateApp and DeleteApp", func(t *testing.T) {
+		records = nil
+		app, err := c.CreateApp(&RequestParam{Name: "bar", Image: "redis", Instances: 1, Plan: "free", Ports: Ports{{Protocol: "tcp", Number: 6379}}})
+		require.NoError(t, err)
+		require.NoError(t, c.DeleteApp(app.Data.ID))
+
+		require.Len(t, records, 2)
+		assert.Equal(t, "CreateApp", records[0].Operation)
+		assert.Equal(t, app.Data.ID, records[0].TargetID)
+		assert.Nil(t, records[0].Caller)
+		assert.Equal(t, "DeleteApp", records[1].Operation)
+		assert.NotNil(t, records[1].Before)
+		assert.Nil(t, records[1].After)
+	})
+}
+
+// slowReadClient delays reads to check they are not included in AuditRecord.DurationMS
+type slowReadClient struct {
+	*testClient
+}
+
+func (c *slowReadClient) ReadService(id string) (*ServiceData, error) {
+	time.Sleep(100 * time.Millisecond)
+	return c.testClient.ReadService(id)
+}
+
+func TestAuditClient_Duration(t *testing.T) {
+	base := newTestClient()
+	base.addApp(testAppID, testServiceID, "foo", &ServiceAttr{Image: "nginx", Instances: 1})
+
+	var records []*AuditRecord
+	c, err := NewAuditClient(&slowReadClient{base}, &AuditParam{Sink: AuditSinkFunc(func(r *AuditRecord) error {
+		records = append(records, r)
+		return nil
+	})})
+	require.NoError(t, err)
+
+	require.NoError(t, c.PowerOn(testServiceID))
+	require.Len(t, records, 1)
+	assert.NotNil(t, records[0].Before)
+	assert.NotNil(t, records[0].After)
+	assert.True(t, records[0].DurationMS < 100, "reads must not be included: %dms", records[0].DurationMS)
+}
+
+func TestAuditClient_SinkError(t *testing.T) {
+	base := newTestClient()
+	base.addApp(testAppID, testServiceID, "foo", &ServiceAttr{Image: "nginx", Instances: 1})
+
+	var sinkErr error
+	c, err := NewAuditClient(base, &AuditParam{
+		Sink:        AuditSinkFunc(func(r *AuditRecord) error { return errors.New("disk full") }),
+		OnSinkError: func(r *AuditRecord, err error) { sinkErr = err },
+	})
+	require.NoError(t, err)
+
+	assert.NoError(t, c.PowerOff(testServiceID))
+	assert.EqualError(t, sinkErr, "disk full")
+
+	_, err = NewAuditClient(base, &AuditParam{})
+	assert.Error(t, err)
+}
+
+func TestAuditSinks(t *testing.T) {
+	record := &AuditRecord{Operation: "PowerOn", TargetID: testServiceID, Outcome: AuditSuccess}
+
+	t.Run("writer", func(t *testing.T) {
+		buf := &bytes.Buffer{}
+		sink := NewAuditWriterSink(buf)
+		require.NoError(t, sink.WriteAuditRecord(record))
+		require.NoError(t, sink.WriteAuditRecord(record))
+
+		lines := strings.Split(strings.TrimSpace(buf.String()), "\n")
+		require.Len(t, lines, 2)
+		var got AuditRecord
+		require.NoError(t, json.Unmarshal([]byte(lines[0]), &got))
+		assert.Equal(t, "PowerOn", got.Operation)
+	})
+
+	t.Run("file", func(t *testing.T) {
+		dir, err := ioutil.TempDir("", "arukas-audit")
+		require.NoError(t, err)
+		defer os.RemoveAll(dir) // nolint
+		path := filepath.Join(dir, "audit.jsonl")
+
+		for i := 0; i < 2; i++ {
+			sink, err := NewAuditFileSink(path)
+			require.NoError(t, err)
+			require.NoError(t, sink.WriteAuditRecord(record))
+			require.NoError(t, sink.Close())
+		}
+
+		data, err := ioutil.ReadFile(path)
+		require.NoError(t, err)
+		assert.Equal(t, 2, strings.Count(string(data), "\n"))
+	})
+}
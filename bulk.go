@@ -0,0 +1,241 @@
+package arukas
+
+import (
+	"errors"
+	"fmt"
+	"sync"
+
+	"github.com/hashicorp/go-multierror"
+)
+
+const defaultBulkConcurrency = 4
+
+// BulkParam represents parameters of bulk operations
+type BulkParam struct {
+	// IDs is a list of target IDs.
+	// Service IDs for BulkPowerOn/BulkPowerOff/BulkUpdate, App IDs for BulkDelete.
+	IDs []string
+	// ServiceFilter selects target services from ListServices, used when IDs is empty
+	ServiceFilter func(s *Service) bool
+	// AppFilter selects target apps from ListApps, used by BulkDelete when IDs is empty
+	AppFilter func(a *App) bool
+	// Concurrency is the max number of operations running at the same time.
+	// If zero, default value(4) is used.
+	Concurrency int
+	// FailFast stops starting remaining operations after first error.
+	// If false, all operations are performed (best-effort).
+	FailFast bool
+}
+
+// BulkItemResult represents result of an operation for single target
+type BulkItemResult struct {
+	ID string
+	// Skipped is true if the operation was not performed due to FailFast
+	Skipped bool
+	Err     error
+}
+
+// Succeeded returns true if the operation was performed without error
+func (r *BulkItemResult) Succeeded() bool {
+	return !r.Skipped && r.Err == nil
+}
+
+// BulkResult represents report of a bulk operation
+type BulkResult struct {
+	// Items are ordered same as target IDs
+	Items []*BulkItemResult
+}
+
+// Succeeded returns IDs which the operation was succeeded
+func (r *BulkResult) Succeeded() []string {
+	var ids []string
+	for _, item := range r.Items {
+		if item.Succeeded() {
+			ids = append(ids, item.ID)
+		}
+	}
+	return ids
+}
+
+// Failed returns results which the operation was failed
+func (r *BulkResult) Failed() []*BulkItemResult {
+	var items []*BulkItemResult
+	for _, item := range r.Items {
+		if item.Err != nil {
+			items = append(items, item)
+		}
+	}
+	return items
+}
+
+// BulkPowerOn powers on services
+func BulkPowerOn(c Client, p *BulkParam) (*BulkResult, error) {
+	ids, err := p.serviceIDs(c)
+	if err != nil {
+		return nil, err
+	}
+	return p.run(ids, c.PowerOn)
+}
+
+// BulkPowerOff powers off services
+func BulkPowerOff(c Client, p *BulkParam) (*BulkResult, error) {
+	ids, err := p.serviceIDs(c)
+	if err != nil {
+		return nil, err
+	}
+	return p.run(ids, c.PowerOff)
+}
+
+// BulkUpdate updates services with same parameter
+func BulkUpdate(c Client, p *BulkParam, param *RequestParam) (*BulkResult, error) {
+	if param == nil {
+		return nil, errors.New("param is nil")
+	}
+	if err := param.ValidateForUpdate(); err != nil {
+		return nil, err
+	}
+	ids, err := p.serviceIDs(c)
+	if err != nil {
+		return nil, err
+	}
+	return p.run(ids, func(id string) error {
+		_, err := c.UpdateService(id, param)
+		return err
+	})
+}
+
+// BulkDelete deletes apps
+func BulkDelete(c Client, p *BulkParam) (*BulkResult, error) {
+	ids, err := p.appIDs(c)
+	if err != nil {
+		return nil, err
+	}
+	return p.run(ids, c.DeleteApp)
+}
+
+func (p *BulkParam) validate(hasFilter bool) error {
+	var results error
+	if len(p.IDs) == 0 && !hasFilter {
+		results = multierror.Append(results, errors.New("IDs or Filter is required"))
+	}
+	for _, id := range p.IDs {
+		if err := validateRequired("ID", id); err != nil {
+			results = multierror.Append(results, err)
+			continue
+		}
+		if err := validateID("ID", id); err != nil {
+			results = multierror.Append(results, err)
+		}
+	}
+	if p.Concurrency < 0 {
+		results = multierror.Append(results, errors.New(`"Concurrency" must be greater than or equal to 0`))
+	}
+	return results
+}
+
+func (p *BulkParam) serviceIDs(c Client) ([]string, error) {
+	if p == nil {
+		return nil, errors.New("BulkParam is nil")
+	}
+	if err := p.validate(p.ServiceFilter != nil); err != nil {
+		return nil, err
+	}
+	if len(p.IDs) > 0 {
+		return p.IDs, nil
+	}
+
+	list, err := c.ListServices()
+	if err != nil {
+		return nil, err
+	}
+	var ids []string
+	for _, s := range list.Data {
+		if p.ServiceFilter(s) {
+			ids = append(ids, s.ID)
+		}
+	}
+	return ids, nil
+}
+
+func (p *BulkParam) appIDs(c Client) ([]string, error) {
+	if p == nil {
+		return nil, errors.New("BulkParam is nil")
+	}
+	if err := p.validate(p.AppFilter != nil); err != nil {
+		return nil, err
+	}
+	if len(p.IDs) > 0 {
+		return p.IDs, nil
+	}
+
+	list, err := c.ListApps()
+	if err != nil {
+		return nil, err
+	}
+	var ids []string
+	for _, a := range list.Data {
+		if p.AppFilter(a) {
+			ids = append(ids, a.ID)
+		}
+	}
+	return ids, nil
+}
+
+// run performs fn for each id with bounded concurrency.
+// Returned error is aggregated errors of each operation.
+func (p *BulkParam) run(ids []string, fn func(id string) error) (*BulkResult, error) {
+	concurrency := p.Concurrency
+	if concurrency == 0 {
+		concurrency = defaultBulkConcurrency
+	}
+
+	result := &BulkResult{Items: make([]*BulkItemResult, len(ids))}
+	for i, id := range ids {
+		result.Items[i] = &BulkItemResult{ID: id}
+	}
+
+	var (
+		wg     sync.WaitGroup
+		mu     sync.Mutex
+		failed bool
+	)
+	sem := make(chan struct{}, concurrency)
+
+	for _, item := range result.Items {
+		sem <- struct{}{}
+
+		mu.Lock()
+		stop := failed && p.FailFast
+		mu.Unlock()
+		if stop {
+			<-sem
+			item.Skipped = true
+			continue
+		}
+
+		wg.Add(1)
+		go func(item *BulkItemResult) {
+			defer func() {
+				<-sem
+				wg.Done()
+			}()
+			err := fn(item.ID)
+
+			mu.Lock()
+			defer mu.Unlock()
+			item.Err = err
+			if err != nil {
+				failed = true
+			}
+		}(item)
+	}
+	wg.Wait()
+
+	var results error
+	for _, item := range result.Items {
+		if item.Err != nil {
+			results = multierror.Append(results, fmt.Errorf("%s: %s", item.ID, item.Err))
+		}
+	}
+	return result, results
+}
@@ -0,0 +1,138 @@
+package arukas
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/hashicorp/go-multierror"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestBulkParam_Validate(t *testing.T) {
+
+	expects := []struct {
+		scenario string
+		expect   bool
+		param    *BulkParam
+	}{
+		{
+			scenario: "IDs and Filter are empty",
+			expect:   false,
+			param:    &BulkParam{},
+		},
+		{
+			scenario: "Invalid ID",
+			expect:   false,
+			param: &BulkParam{
+				IDs: []string{"foobar"},
+			},
+		},
+		{
+			scenario: "Negative concurrency",
+			expect:   false,
+			param: &BulkParam{
+				IDs:         []string{testServiceID},
+				Concurrency: -1,
+			},
+		},
+		{
+			scenario: "Valid IDs",
+			expect:   true,
+			param: &BulkParam{
+				IDs: []string{testServiceID, testAnotherSvcID},
+			},
+		},
+		{
+			scenario: "Valid Filter",
+			expect:   true,
+			param: &BulkParam{
+				ServiceFilter: func(s *Service) bool { return true },
+			},
+		},
+	}
+
+	for _, expect := range expects {
+		t.Run(expect.scenario, func(t *testing.T) {
+			err := expect.param.validate(expect.param.ServiceFilter != nil)
+			assert.Equal(t, expect.expect, err == nil)
+		})
+	}
+}
+
+func TestBulkPowerOn(t *testing.T) {
+
+	t.Run("With IDs", func(t *testing.T) {
+		c := newTestClient()
+		c.addApp(testAppID, testServiceID, "foo", nil)
+		c.addApp(testAnotherAppID, testAnotherSvcID, "bar", nil)
+
+		res, err := BulkPowerOn(c, &BulkParam{
+			IDs: []string{testServiceID, testAnotherSvcID},
+		})
+		assert.NoError(t, err)
+		assert.Equal(t, []string{testServiceID, testAnotherSvcID}, res.Succeeded())
+		assert.Equal(t, 2, c.called("PowerOn"))
+	})
+
+	t.Run("With Filter", func(t *testing.T) {
+		c := newTestClient()
+		c.addApp(testAppID, testServiceID, "foo", &ServiceAttr{Image: "nginx:latest"})
+		c.addApp(testAnotherAppID, testAnotherSvcID, "bar", &ServiceAttr{Image: "httpd:latest"})
+
+		res, err := BulkPowerOn(c, &BulkParam{
+			ServiceFilter: func(s *Service) bool { return s.Image() == "nginx:latest" },
+		})
+		assert.NoError(t, err)
+		assert.Equal(t, []string{testServiceID}, res.Succeeded())
+
+		s, err := c.ReadService(testServiceID)
+		assert.NoError(t, err)
+		assert.Equal(t, StatusRunning, s.Status())
+		s, err = c.ReadService(testAnotherSvcID)
+		assert.NoError(t, err)
+		assert.Equal(t, StatusStopped, s.Status())
+	})
+}
+
+func TestBulkPowerOff_Errors(t *testing.T) {
+	ids := []string{testServiceID, testAnotherSvcID}
+
+	t.Run("Best-effort", func(t *testing.T) {
+		c := newTestClient()
+		c.errors["PowerOff"] = errors.New("dummy")
+
+		res, err := BulkPowerOff(c, &BulkParam{IDs: ids})
+		assert.Error(t, err)
+		assert.Len(t, err.(*multierror.Error).Errors, 2)
+		assert.Len(t, res.Failed(), 2)
+		assert.Equal(t, 2, c.called("PowerOff"))
+	})
+
+	t.Run("Fail-fast", func(t *testing.T) {
+		c := newTestClient()
+		c.errors["PowerOff"] = errors.New("dummy")
+
+		res, err := BulkPowerOff(c, &BulkParam{IDs: ids, Concurrency: 1, FailFast: true})
+		assert.Error(t, err)
+		assert.Len(t, res.Failed(), 1)
+		assert.True(t, res.Items[1].Skipped)
+		assert.Equal(t, 1, c.called("PowerOff"))
+	})
+}
+
+func TestBulkDelete(t *testing.T) {
+	c := newTestClient()
+	c.addApp(testAppID, testServiceID, "foo", nil)
+	c.addApp(testAnotherAppID, testAnotherSvcID, "bar", nil)
+
+	res, err := BulkDelete(c, &BulkParam{
+		AppFilter: func(a *App) bool { return a.Name() == "bar" },
+	})
+	assert.NoError(t, err)
+	assert.Equal(t, []string{testAnotherAppID}, res.Succeeded())
+
+	list, err := c.ListApps()
+	assert.NoError(t, err)
+	assert.Len(t, list.Data, 1)
+	assert.Equal(t, testAppID, list.Data[0].ID)
+}
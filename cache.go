@@ -0,0 +1,316 @@
+package arukas
+
+import (
+	"context"
+	"errors"
+	"net/url"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+const defaultCacheTTL = 30 * time.Second
+
+const (
+	cacheKeyApps          = "apps"
+	cacheKeyServices      = "services"
+	cacheKeyAppPrefix     = "app:"
+	cacheKeyServicePrefix = "service:"
+	cacheKeyPlans         = "plans"
+)
+
+// CacheStats represents hit/miss statistics of CachedClient
+type CacheStats struct {
+	Hits          uint64
+	Misses        uint64
+	Coalesced     uint64
+	Invalidations uint64
+}
+
+// CachedClient is a read-through cache that wraps Client.
+//
+// Results of ListApps/ReadApp/ListServices/ReadService/ListPlans are kept until TTL expires,
+// and invalidated automatically when mutations are performed through CachedClient.
+// Concurrent identical reads are coalesced into a single API call.
+// Cached values are shared between callers, so callers must not modify them.
+type CachedClient struct {
+	// counters are accessed atomically, keep them 64-bit aligned
+	hits          uint64
+	misses        uint64
+	coalesced     uint64
+	invalidations uint64
+
+	Client
+	ttl time.Duration
+
+	mu       sync.Mutex
+	entries  map[string]*cacheEntry
+	inflight map[string]*cacheCall
+	// generation is incremented on every invalidation,
+	// results of calls started before invalidation are not cached.
+	generation uint64
+}
+
+type cacheEntry struct {
+	value     interface{}
+	expiresAt time.Time
+}
+
+type cacheCall struct {
+	wg    sync.WaitGroup
+	value interface{}
+	err   error
+}
+
+// NewCachedClient returns a new CachedClient wrapping c.
+// If ttl is zero or negative, default TTL(30 seconds) is used.
+func NewCachedClient(c Client, ttl time.Duration) *CachedClient {
+	if ttl <= 0 {
+		ttl = defaultCacheTTL
+	}
+	return &CachedClient{
+		Client:   c,
+		ttl:      ttl,
+		entries:  map[string]*cacheEntry{},
+		inflight: map[string]*cacheCall{},
+	}
+}
+
+// Stats returns current hit/miss statistics
+func (c *CachedClient) Stats() CacheStats {
+	return CacheStats{
+		Hits:          atomic.LoadUint64(&c.hits),
+		Misses:        atomic.LoadUint64(&c.misses),
+		Coalesced:     atomic.LoadUint64(&c.coalesced),
+		Invalidations: atomic.LoadUint64(&c.invalidations),
+	}
+}
+
+// Purge removes all cached entries
+func (c *CachedClient) Purge() {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.entries = map[string]*cacheEntry{}
+	c.generation++
+	atomic.AddUint64(&c.invalidations, 1)
+}
+
+// ListApps implements Client interface
+func (c *CachedClient) ListApps() (*AppListData, error) {
+	v, err := c.load(cacheKeyApps, func() (interface{}, error) {
+		return c.Client.ListApps()
+	})
+	if err != nil {
+		return nil, err
+	}
+	return v.(*AppListData), nil
+}
+
+// ReadApp implements Client interface
+func (c *CachedClient) ReadApp(id string) (*AppData, error) {
+	v, err := c.load(cacheKeyAppPrefix+id, func() (interface{}, error) {
+		return c.Client.ReadApp(id)
+	})
+	if err != nil {
+		return nil, err
+	}
+	return v.(*AppData), nil
+}
+
+// CreateApp implements Client interface
+func (c *CachedClient) CreateApp(param *RequestParam) (*AppData, error) {
+	app, err := c.Client.CreateApp(param)
+	c.invalidate(cacheKeyApps, cacheKeyServices)
+	return app, err
+}
+
+// CreateAppWithSpec implements Client interface
+func (c *CachedClient) CreateAppWithSpec(spec *AppSpec) (*AppData, error) {
+	app, err := c.Client.CreateAppWithSpec(spec)
+	c.invalidate(cacheKeyApps, cacheKeyServices)
+	return app, err
+}
+
+// UpdateApp implements Client interface
+func (c *CachedClient) UpdateApp(ctx context.Context, id string, patch AppPatch) (*AppData, error) {
+	app, err := c.Client.UpdateApp(ctx, id, patch)
+	c.invalidate(cacheKeyApps, cacheKeyAppPrefix+id)
+	return app, err
+}
+
+// DeleteApp implements Client interface
+func (c *CachedClient) DeleteApp(id string) error {
+	err := c.Client.DeleteApp(id)
+	c.invalidate(cacheKeyApps, cacheKeyServices, cacheKeyAppPrefix+id)
+	// child services of the app are unknown here, so drop all of them
+	c.invalidatePrefix(cacheKeyServicePrefix)
+	return err
+}
+
+// ListServices implements Client interface
+func (c *CachedClient) ListServices() (*ServiceListData, error) {
+	v, err := c.load(cacheKeyServices, func() (interface{}, error) {
+		return c.Client.ListServices()
+	})
+	if err != nil {
+		return nil, err
+	}
+	return v.(*ServiceListData), nil
+}
+
+// ReadService implements Client interface
+func (c *CachedClient) ReadService(id string) (*ServiceData, error) {
+	v, err := c.load(cacheKeyServicePrefix+id, func() (interface{}, error) {
+		return c.Client.ReadService(id)
+	})
+	if err != nil {
+		return nil, err
+	}
+	return v.(*ServiceData), nil
+}
+
+// UpdateService implements Client interface
+func (c *CachedClient) UpdateService(id string, param *RequestParam) (*ServiceData, error) {
+	service, err := c.Client.UpdateService(id, param)
+	c.invalidateService(id)
+	return service, err
+}
+
+// PatchService implements Client interface
+func (c *CachedClient) PatchService(ctx context.Context, id string, patch *ServicePatch) (*ServiceData, error) {
+	service, err := c.Client.PatchService(ctx, id, patch)
+	c.invalidateService(id)
+	return service, err
+}
+
+// PowerOn implements Client interface
+func (c *CachedClient) PowerOn(id string) error {
+	err := c.Client.PowerOn(id)
+	c.invalidateService(id)
+	return err
+}
+
+// PowerOff implements Client interface
+func (c *CachedClient) PowerOff(id string) error {
+	err := c.Client.PowerOff(id)
+	c.invalidateService(id)
+	return err
+}
+
+// WaitForState implements Client interface.
+// It always polls the wrapped Client and drops cached service on return.
+func (c *CachedClient) WaitForState(ctx context.Context, serviceID string, status string) error {
+	err := c.Client.WaitForState(ctx, serviceID, status)
+	c.invalidateService(serviceID)
+	return err
+}
+
+// ListPlans implements Client interface
+func (c *CachedClient) ListPlans(ctx context.Context) ([]*Plan, error) {
+	v, err := c.load(cacheKeyPlans, func() (interface{}, error) {
+		return c.Client.ListPlans(ctx)
+	})
+	if err != nil {
+		return nil, err
+	}
+	return v.([]*Plan), nil
+}
+
+// invalidateService drops caches which may contain the service.
+// Apps include their services, so all app caches are dropped too.
+func (c *CachedClient) invalidateService(id string) {
+	c.invalidate(cacheKeyApps, cacheKeyServices, cacheKeyServicePrefix+id)
+	c.invalidatePrefix(cacheKeyAppPrefix)
+}
+
+func (c *CachedClient) invalidate(keys ...string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.generation++
+	for _, k := range keys {
+		if _, ok := c.entries[k]; ok {
+			delete(c.entries, k)
+			atomic.AddUint64(&c.invalidations, 1)
+		}
+	}
+}
+
+func (c *CachedClient) invalidatePrefix(prefix string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.generation++
+	for k := range c.entries {
+		if strings.HasPrefix(k, prefix) {
+			delete(c.entries, k)
+			atomic.AddUint64(&c.invalidations, 1)
+		}
+	}
+}
+
+// load returns cached value of key, or calls fn and caches its result.
+// Concurrent calls with same key are coalesced into single fn call.
+// If the coalesced call fails by its caller's context, fn of the waiter is called instead,
+// so a cancelled caller doesn't fail other callers.
+func (c *CachedClient) load(key string, fn func() (interface{}, error)) (interface{}, error) {
+	c.mu.Lock()
+	if e, ok := c.entries[key]; ok {
+		if time.Now().Before(e.expiresAt) {
+			c.mu.Unlock()
+			atomic.AddUint64(&c.hits, 1)
+			return e.value, nil
+		}
+		delete(c.entries, key)
+	}
+	if call, ok := c.inflight[key]; ok {
+		c.mu.Unlock()
+		atomic.AddUint64(&c.coalesced, 1)
+		call.wg.Wait()
+		if isContextError(call.err) {
+			return c.load(key, fn)
+		}
+		return call.value, call.err
+	}
+
+	call := &cacheCall{}
+	call.wg.Add(1)
+	c.inflight[key] = call
+	generation := c.generation
+	c.mu.Unlock()
+
+	atomic.AddUint64(&c.misses, 1)
+	c.do(key, call, generation, fn)
+	return call.value, call.err
+}
+
+// do calls fn and stores its result to call.
+// Waiters of call are released even if fn panics.
+func (c *CachedClient) do(key string, call *cacheCall, generation uint64, fn func() (interface{}, error)) {
+	completed := false
+	defer func() {
+		if !completed {
+			call.value, call.err = nil, errors.New("cached call panicked")
+		}
+		c.mu.Lock()
+		delete(c.inflight, key)
+		if completed && call.err == nil && generation == c.generation {
+			c.entries[key] = &cacheEntry{
+				value:     call.value,
+				expiresAt: time.Now().Add(c.ttl),
+			}
+		}
+		c.mu.Unlock()
+		call.wg.Done()
+	}()
+
+	call.value, call.err = fn()
+	completed = true
+}
+
+// isContextError returns true if err is caused by cancellation or deadline of context
+func isContextError(err error) bool {
+	if e, ok := err.(*url.Error); ok {
+		err = e.Err
+	}
+	return err == context.Canceled || err == context.DeadlineExceeded
+}
@@ -0,0 +1,208 @@
+package arukas
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCachedClient_Read(t *testing.T) {
+
+	t.Run("Hit after first read", func(t *testing.T) {
+		base := newTestClient()
+		base.addApp(testAppID, testServiceID, "foobar", nil)
+		c := NewCachedClient(base, time.Minute)
+
+		for i := 0; i < 3; i++ {
+			s, err := c.ReadService(testServiceID)
+			assert.NoError(t, err)
+			assert.Equal(t, testServiceID, s.ServiceID())
+		}
+		assert.Equal(t, 1, base.called("ReadService"))
+		assert.Equal(t, CacheStats{Hits: 2, Misses: 1}, c.Stats())
+	})
+
+	t.Run("Expire after TTL", func(t *testing.T) {
+		base := newTestClient()
+		base.addApp(testAppID, testServiceID, "foobar", nil)
+		c := NewCachedClient(base, 10*time.Millisecond)
+
+		_, err := c.ListApps()
+		assert.NoError(t, err)
+		time.Sleep(20 * time.Millisecond)
+		_, err = c.ListApps()
+		assert.NoError(t, err)
+
+		assert.Equal(t, 2, base.called("ListApps"))
+	})
+
+	t.Run("Errors are not cached", func(t *testing.T) {
+		base := newTestClient()
+		base.errors["ReadApp"] = errors.New("dummy")
+		c := NewCachedClient(base, time.Minute)
+
+		_, err := c.ReadApp(testAppID)
+		assert.Error(t, err)
+		_, err = c.ReadApp(testAppID)
+		assert.Error(t, err)
+
+		assert.Equal(t, 2, base.called("ReadApp"))
+	})
+
+	t.Run("Concurrent reads are coalesced", func(t *testing.T) {
+		base := newTestClient()
+		base.addApp(testAppID, testServiceID, "foobar", nil)
+		base.delay = 50 * time.Millisecond
+		c := NewCachedClient(base, time.Minute)
+
+		var wg sync.WaitGroup
+		for i := 0; i < 5; i++ {
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				_, err := c.ListServices()
+				assert.NoError(t, err)
+			}()
+		}
+		wg.Wait()
+
+		assert.Equal(t, 1, base.called("ListServices"))
+		stats := c.Stats()
+		assert.Equal(t, uint64(1), stats.Misses)
+		assert.Equal(t, uint64(4), stats.Hits+stats.Coalesced)
+	})
+
+	t.Run("Cancelled caller doesn't fail coalesced callers", func(t *testing.T) {
+		base := newTestClient()
+		base.delay = 50 * time.Millisecond
+		c := NewCachedClient(base, time.Minute)
+
+		cancelled, cancel := context.WithCancel(context.Background())
+		cancel()
+		done := make(chan error)
+		go func() {
+			_, err := c.ListPlans(cancelled)
+			done <- err
+		}()
+		time.Sleep(10 * time.Millisecond)
+
+		plans, err := c.ListPlans(context.Background())
+		assert.NoError(t, err)
+		assert.NotEmpty(t, plans)
+		assert.Equal(t, context.Canceled, <-done)
+	})
+
+	t.Run("Panic releases coalesced callers", func(t *testing.T) {
+		c := NewCachedClient(newTestClient(), time.Minute)
+		started := make(chan struct{})
+		release := make(chan struct{})
+
+		go func() {
+			defer func() { _ = recover() }()
+			_, _ = c.load("key", func() (interface{}, error) {
+				close(started)
+				<-release
+				panic("dummy")
+			})
+		}()
+		<-started
+
+		done := make(chan error)
+		go func() {
+			_, err := c.load("key", func() (interface{}, error) { return nil, nil })
+			done <- err
+		}()
+		time.Sleep(10 * time.Millisecond)
+		close(release)
+
+		select {
+		case err := <-done:
+			assert.Error(t, err)
+		case <-time.After(time.Second):
+			t.Fatal("waiter is not released")
+		}
+	})
+}
+
+func TestCachedClient_Invalidate(t *testing.T) {
+
+	expects := []struct {
+		scenario string
+		mutate   func(c Client) error
+	}{
+		{
+			scenario: "UpdateService",
+			mutate: func(c Client) error {
+				_, err := c.UpdateService(testServiceID, validUpdateServiceParam)
+				return err
+			},
+		},
+		{
+			scenario: "PowerOn",
+			mutate: func(c Client) error {
+				return c.PowerOn(testServiceID)
+			},
+		},
+		{
+			scenario: "PowerOff",
+			mutate: func(c Client) error {
+				return c.PowerOff(testServiceID)
+			},
+		},
+		{
+			scenario: "DeleteApp",
+			mutate: func(c Client) error {
+				return c.DeleteApp(testAppID)
+			},
+		},
+		{
+			scenario: "CreateApp",
+			mutate: func(c Client) error {
+				_, err := c.CreateApp(validCreateAppParam)
+				return err
+			},
+		},
+	}
+
+	for _, expect := range expects {
+		t.Run(expect.scenario, func(t *testing.T) {
+			base := newTestClient()
+			base.addApp(testAppID, testServiceID, "foobar", nil)
+			c := NewCachedClient(base, time.Minute)
+
+			_, err := c.ListServices()
+			assert.NoError(t, err)
+			_, err = c.ListApps()
+			assert.NoError(t, err)
+
+			assert.NoError(t, expect.mutate(c))
+
+			_, err = c.ListServices()
+			assert.NoError(t, err)
+			_, err = c.ListApps()
+			assert.NoError(t, err)
+
+			assert.Equal(t, 2, base.called("ListServices"))
+			assert.Equal(t, 2, base.called("ListApps"))
+		})
+	}
+
+	t.Run("Unrelated service is kept", func(t *testing.T) {
+		base := newTestClient()
+		base.addApp(testAppID, testServiceID, "foo", nil)
+		base.addApp(testAnotherAppID, testAnotherSvcID, "bar", nil)
+		c := NewCachedClient(base, time.Minute)
+
+		_, err := c.ReadService(testAnotherSvcID)
+		assert.NoError(t, err)
+		assert.NoError(t, c.PowerOn(testServiceID))
+		_, err = c.ReadService(testAnotherSvcID)
+		assert.NoError(t, err)
+
+		assert.Equal(t, 1, base.called("ReadService"))
+	})
+}
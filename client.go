@@ -64,6 +64,7 @@ func NewClient(p *ClientParam) (Client, error) {
 			trace:      p.Trace,
 			traceOut:   out,
 			timeout:    timeout,
+			portFormat: p.PortFormat,
 		},
 	}, nil
 }
@@ -73,16 +74,21 @@ type Client interface {
 	ListApps() (*AppListData, error)
 	ReadApp(id string) (*AppData, error)
 	CreateApp(param *RequestParam) (*AppData, error)
+	CreateAppWithSpec(spec *AppSpec) (*AppData, error)
+	UpdateApp(ctx context.Context, id string, patch AppPatch) (*AppData, error)
 	DeleteApp(id string) error
 
 	ListServices() (*ServiceListData, error)
 	ReadService(id string) (*ServiceData, error)
 	UpdateService(id string, param *RequestParam) (*ServiceData, error)
+	PatchService(ctx context.Context, id string, patch *ServicePatch) (*ServiceData, error)
 	PowerOn(id string) error
 	PowerOff(id string) error
 
 	WaitForState(ctx context.Context, serviceID string, status string) error
 
+	ListPlans(ctx context.Context) ([]*Plan, error)
+
 	Version() string
 }
 
@@ -127,7 +133,8 @@ func (c *client) ReadApp(id string) (*AppData, error) {
 	return &appData, nil
 }
 
-// CreateApp implements arukas.API interface
+// CreateApp implements arukas.API interface.
+// Uniqueness of the name is not checked, use EnsureApp to avoid creating duplicated apps.
 func (c *client) CreateApp(param *RequestParam) (*AppData, error) {
 	if param == nil {
 		return nil, errors.New("param is nil")
@@ -151,6 +158,80 @@ func (c *client) CreateApp(param *RequestParam) (*AppData, error) {
 	return &appData, nil
 }
 
+// CreateAppWithSpec implements arukas.API interface.
+// Uniqueness of the name is not checked, same as CreateApp.
+func (c *client) CreateAppWithSpec(spec *AppSpec) (*AppData, error) {
+	if spec == nil {
+		return nil, errors.New("spec is nil")
+	}
+	err := spec.ValidateForCreate()
+	if err != nil {
+		return nil, err
+	}
+
+	data, err := c.httpAPI.post("/apps", spec.ToAppData())
+	if err != nil {
+		return nil, err
+	}
+
+	var appData AppData
+	err = json.Unmarshal(data, &appData)
+	if err != nil {
+		return nil, err
+	}
+
+	return &appData, nil
+}
+
+// UpdateApp implements arukas.API interface.
+// The new name must be unique in the account.
+func (c *client) UpdateApp(ctx context.Context, id string, patch AppPatch) (*AppData, error) {
+	if err := validateRequired("ID", id); err != nil {
+		return nil, err
+	}
+	if err := validateID("ID", id); err != nil {
+		return nil, err
+	}
+	if err := patch.Validate(); err != nil {
+		return nil, err
+	}
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	apps, err := c.ListApps()
+	if err != nil {
+		return nil, err
+	}
+	for _, app := range apps.Data {
+		if app.ID != id && app.Attributes != nil && app.Name() == *patch.Name {
+			return nil, appNameConflictError("Name", app.ID)
+		}
+	}
+
+	path := fmt.Sprintf("/apps/%s", id)
+	data, err := c.httpAPI.put(path, &AppData{
+		Data: &App{
+			ID:   id,
+			Type: TypeApps,
+			Attributes: &AppAttr{
+				Name: *patch.Name,
+			},
+		},
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	var appData AppData
+	err = json.Unmarshal(data, &appData)
+	if err != nil {
+		return nil, err
+	}
+
+	return &appData, nil
+}
+
 // DeleteApp implements arukas.API interface
 func (c *client) DeleteApp(id string) error {
 	if err := validateID("ID", id); err != nil {
@@ -221,6 +302,37 @@ func (c *client) UpdateService(id string, param *RequestParam) (*ServiceData, er
 	return &serviceData, nil
 }
 
+// PatchService updates only attributes specified in patch
+func (c *client) PatchService(ctx context.Context, id string, patch *ServicePatch) (*ServiceData, error) {
+	if err := validateID("ID", id); err != nil {
+		return nil, err
+	}
+	if patch == nil {
+		return nil, errors.New("patch is nil")
+	}
+	err := patch.Validate()
+	if err != nil {
+		return nil, err
+	}
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	path := fmt.Sprintf("/services/%s", id)
+	data, err := c.httpAPI.patch(path, patch.toServicePatchData())
+	if err != nil {
+		return nil, err
+	}
+
+	var serviceData ServiceData
+	err = json.Unmarshal(data, &serviceData)
+	if err != nil {
+		return nil, err
+	}
+
+	return &serviceData, nil
+}
+
 func (c *client) PowerOn(id string) error {
 	if err := validateID("ID", id); err != nil {
 		return err
@@ -279,6 +391,36 @@ func (c *client) WaitForState(ctx context.Context, serviceID string, status stri
 
 }
 
+// ListPlans returns available service plans.
+// If the API doesn't provide plans(404 or empty list), a copy of DefaultPlans is returned.
+func (c *client) ListPlans(ctx context.Context) ([]*Plan, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	data, err := c.httpAPI.get("/" + TypeServicePlans)
+	if err != nil {
+		if _, ok := err.(*httpNotFoundError); ok {
+			return defaultPlans(), nil
+		}
+		return nil, err
+	}
+
+	var planListData ServicePlanListData
+	if err := json.Unmarshal(data, &planListData); err != nil {
+		return nil, err
+	}
+	if len(planListData.Data) == 0 {
+		return defaultPlans(), nil
+	}
+
+	plans := make([]*Plan, 0, len(planListData.Data))
+	for _, p := range planListData.Data {
+		plans = append(plans, p.Plan())
+	}
+	return plans, nil
+}
+
 func (c *client) Version() string {
 	return Version
 }
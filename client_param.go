@@ -17,6 +17,8 @@ type ClientParam struct {
 	Trace      bool
 	TraceOut   io.Writer
 	Timeout    time.Duration
+	// PortFormat is wire format of ports in request body. default: PortFormatString
+	PortFormat PortFormat
 }
 
 func (p *ClientParam) validate() error {
@@ -38,5 +40,9 @@ func (p *ClientParam) validate() error {
 		}
 	}
 
+	if err := validateRange("PortFormat", int(p.PortFormat), int(PortFormatString), int(PortFormatAuto)); err != nil {
+		results = multierror.Append(results, err)
+	}
+
 	return results
 }
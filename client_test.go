@@ -22,24 +22,33 @@ type testHTTPAPI struct {
 	postError   error
 	putError    error
 	deleteError error
+
+	// lastPath and lastBody are recorded on each request
+	lastPath string
+	lastBody interface{}
 }
 
 func (c *testHTTPAPI) get(path string) ([]byte, error) {
+	c.lastPath, c.lastBody = path, nil
 	return c.getResult, c.getError
 }
 func (c *testHTTPAPI) patch(path string, body interface{}) ([]byte, error) {
+	c.lastPath, c.lastBody = path, body
 	return c.patchResult, c.patchError
 }
 
 func (c *testHTTPAPI) post(path string, body interface{}) ([]byte, error) {
+	c.lastPath, c.lastBody = path, body
 	return c.postResult, c.postError
 }
 
 func (c *testHTTPAPI) put(path string, body interface{}) ([]byte, error) {
+	c.lastPath, c.lastBody = path, body
 	return c.putResult, c.putError
 }
 
 func (c *testHTTPAPI) delete(path string) error {
+	c.lastPath, c.lastBody = path, nil
 	return c.deleteError
 }
 
@@ -63,6 +72,12 @@ var validUpdateServiceParam = &RequestParam{
 
 var testServiceID = "01BEF829-72E4-48F9-81DA-E3B41A1EDAC9" // valid UUID
 
+var (
+	testAppID        = "6E3A5AB1-7D2B-4D4B-9C4E-2C5E8F1D1A01" // valid UUID
+	testAnotherAppID = "6E3A5AB1-7D2B-4D4B-9C4E-2C5E8F1D1A02" // valid UUID
+	testAnotherSvcID = "01BEF829-72E4-48F9-81DA-E3B41A1EDAC8" // valid UUID
+)
+
 func TestCreateApp(t *testing.T) {
 	t.Run("Invalid parameter", func(t *testing.T) {
 		c := &client{
@@ -126,6 +141,129 @@ func TestCreateApp(t *testing.T) {
 	})
 }
 
+func TestCreateAppWithSpec(t *testing.T) {
+	t.Run("Invalid parameter", func(t *testing.T) {
+		c := &client{
+			httpAPI: &testHTTPAPI{},
+		}
+
+		res, err := c.CreateAppWithSpec(&AppSpec{})
+		assert.Error(t, err)
+		assert.Nil(t, res)
+	})
+
+	t.Run("POST /apps succeed", func(t *testing.T) {
+		c := &client{
+			httpAPI: &testHTTPAPI{
+				postResult: []byte(`{
+					"data": {
+						"id": "` + testAppID + `",
+						"type": "apps",
+						"relationships": {
+							"services": {
+								"data": [
+									{"id": "` + testServiceID + `", "type": "services"},
+									{"id": "` + testAnotherSvcID + `", "type": "services"}
+								]
+							}
+						}
+					},
+					"included": [
+						{"id": "` + testServiceID + `", "type": "services", "attributes": {"image": "nginx:latest"}},
+						{"id": "` + testAnotherSvcID + `", "type": "services", "attributes": {"image": "redis:latest"}},
+						{"id": "foobar", "type": "users"}
+					]
+				}`),
+			},
+		}
+
+		res, err := c.CreateAppWithSpec(validAppSpec)
+		assert.NoError(t, err)
+		assert.Equal(t, []string{testServiceID, testAnotherSvcID}, res.ServiceIDs())
+
+		services := res.Services()
+		assert.Len(t, services, 2)
+		assert.Equal(t, "redis:latest", services[1].Image())
+	})
+}
+
+func TestUpdateApp(t *testing.T) {
+	appList := []byte(`{
+		"data": [
+			{"id": "` + testAppID + `", "type": "apps", "attributes": {"name": "foo"}},
+			{"id": "` + testAnotherAppID + `", "type": "apps", "attributes": {"name": "bar"}}
+		]
+	}`)
+
+	t.Run("Invalid name", func(t *testing.T) {
+		c := &client{
+			httpAPI: &testHTTPAPI{getResult: appList},
+		}
+
+		res, err := c.UpdateApp(context.Background(), testAppID, AppPatch{Name: String("foo bar")})
+		assert.Error(t, err)
+		assert.Nil(t, res)
+	})
+
+	t.Run("Name is already used", func(t *testing.T) {
+		c := &client{
+			httpAPI: &testHTTPAPI{getResult: appList},
+		}
+
+		res, err := c.UpdateApp(context.Background(), testAppID, AppPatch{Name: String("bar")})
+		assert.Error(t, err)
+		assert.Nil(t, res)
+		errs := ValidationErrors(err)
+		assert.Len(t, errs, 1)
+		assert.Equal(t, "Name", errs[0].Field)
+	})
+
+	t.Run("PUT /apps/:id succeed", func(t *testing.T) {
+		api := &testHTTPAPI{
+			getResult: appList,
+			putResult: []byte(`{"data": {"id": "` + testAppID + `", "type": "apps", "attributes": {"name": "baz"}}}`),
+		}
+		c := &client{httpAPI: api}
+
+		res, err := c.UpdateApp(context.Background(), testAppID, AppPatch{Name: String("baz")})
+		assert.NoError(t, err)
+		assert.Equal(t, "baz", res.Name())
+
+		body, err := json.Marshal(api.lastBody)
+		assert.NoError(t, err)
+		assert.Equal(t, "/apps/"+testAppID, api.lastPath)
+		assert.JSONEq(t, `{"data": {"id": "`+testAppID+`", "type": "apps", "attributes": {"name": "baz"}}}`, string(body))
+	})
+}
+
+func TestPatchService(t *testing.T) {
+	t.Run("Invalid parameter", func(t *testing.T) {
+		c := &client{
+			httpAPI: &testHTTPAPI{},
+		}
+
+		res, err := c.PatchService(context.Background(), testServiceID, &ServicePatch{})
+		assert.Error(t, err)
+		assert.Nil(t, res)
+	})
+
+	t.Run("PATCH /services/:id sends only specified attributes", func(t *testing.T) {
+		api := &testHTTPAPI{
+			patchResult: []byte(`{"data": {"id": "` + testServiceID + `", "attributes": {"instances": 3}}}`),
+		}
+		c := &client{httpAPI: api}
+
+		res, err := c.PatchService(context.Background(), testServiceID, &ServicePatch{Instances: Int32(3)})
+		assert.NoError(t, err)
+		assert.Equal(t, int32(3), res.Instances())
+
+		body, err := json.Marshal(api.lastBody)
+		assert.NoError(t, err)
+		assert.Equal(t, "/services/"+testServiceID, api.lastPath)
+		assert.JSONEq(t, `{"data": {"attributes": {"instances": 3}}}`, string(body))
+	})
+}
+
 func TestWaitForStatus(t *testing.T) {
 	getServiceData := func(status string) []byte {
 		service := &ServiceData{
@@ -187,6 +325,83 @@ func TestWaitForStatus(t *testing.T) {
 
 }
 
+func TestListPlans(t *testing.T) {
+	t.Run("GET /service-plans returns 404", func(t *testing.T) {
+		c := &client{
+			httpAPI: &testHTTPAPI{
+				getError: ErrorNotFound(&httpNotFoundError{url: "/service-plans"}),
+			},
+		}
+
+		plans, err := c.ListPlans(context.Background())
+		assert.NoError(t, err)
+		assert.Equal(t, DefaultPlans, plans)
+
+		// returned plans must not share DefaultPlans
+		plans[0].FreeTier.MaxServices = 100
+		assert.Equal(t, int32(1), DefaultPlans[0].FreeTier.MaxServices)
+	})
+
+	t.Run("GET /service-plans returns empty list", func(t *testing.T) {
+		c := &client{
+			httpAPI: &testHTTPAPI{getResult: []byte(`{"data": []}`)},
+		}
+
+		plans, err := c.ListPlans(context.Background())
+		assert.NoError(t, err)
+		assert.Equal(t, DefaultPlans, plans)
+	})
+
+	t.Run("GET /service-plans returns error", func(t *testing.T) {
+		c := &client{
+			httpAPI: &testHTTPAPI{
+				getError: errors.New("dummy"),
+			},
+		}
+
+		_, err := c.ListPlans(context.Background())
+		assert.Error(t, err)
+	})
+
+	t.Run("GET /service-plans returns invalid JSON", func(t *testing.T) {
+		c := &client{
+			httpAPI: &testHTTPAPI{getResult: []byte(`{`)},
+		}
+
+		_, err := c.ListPlans(context.Background())
+		assert.Error(t, err)
+	})
+
+	t.Run("GET /service-plans succeed", func(t *testing.T) {
+		c := &client{
+			httpAPI: &testHTTPAPI{
+				getResult: []byte(`{
+					"data": [
+						{"id": "jp-tokyo/free", "type": "service-plans", "attributes": {"memory": 256}},
+						{"id": "jp-osaka/hobby", "type": "service-plans", "attributes": {"cpus": 0.5, "max-instances": 5, "price": 600}}
+					]
+				}`),
+			},
+		}
+
+		plans, err := c.ListPlans(context.Background())
+		assert.NoError(t, err)
+		assert.Len(t, plans, 2)
+
+		assert.Equal(t, RegionJPTokyo, plans[0].Region)
+		assert.Equal(t, PlanFree, plans[0].Name)
+		assert.Equal(t, int32(256), plans[0].MemoryMB)
+		assert.Equal(t, int32(1), plans[0].MaxInstances)
+		assert.True(t, plans[0].IsFree())
+		assert.True(t, plans[0].FreeTier != DefaultPlans[0].FreeTier, "FreeTier must not be shared with DefaultPlans")
+
+		assert.Equal(t, "jp-osaka", plans[1].Region)
+		assert.Equal(t, PlanHobby, plans[1].Name)
+		assert.Equal(t, int32(5), plans[1].MaxInstances)
+		assert.Equal(t, float64(600), plans[1].MonthlyPrice)
+	})
+}
+
 func TestAccAppCRUD(t *testing.T) {
 	if !isAccTest() {
 		t.SkipNow()
@@ -1,10 +1,16 @@
 package arukas
 
 import (
+	"context"
 	"fmt"
 	"log"
 	"os"
+	"sort"
+	"sync"
 	"testing"
+	"time"
+
+	"github.com/google/uuid"
 )
 
 var realHTTPClient *httpClient
@@ -46,3 +52,387 @@ func TestMain(m *testing.M) {
 func isAccTest() bool {
 	return os.Getenv("TEST_ACC") != ""
 }
+
+// testClient is in-memory Client implementation, used to test Client wrappers
+type testClient struct {
+	mu       sync.Mutex
+	apps     map[string]*App
+	services map[string]*Service
+	calls    map[string]int
+	errors   map[string]error
+	delay    time.Duration
+}
+
+func newTestClient() *testClient {
+	return &testClient{
+		apps:     map[string]*App{},
+		services: map[string]*Service{},
+		calls:    map[string]int{},
+		errors:   map[string]error{},
+	}
+}
+
+// testRequestParam returns a valid RequestParam to create an app, tests modify only fields they care about
+func testRequestParam(name string) *RequestParam {
+	return &RequestParam{
+		Name:      name,
+		Image:     "nginx",
+		Instances: 1,
+		Ports:     Ports{{Number: 80, Protocol: "tcp"}},
+		Plan:      PlanFree,
+	}
+}
+
+// addApp adds app and its service, returns service
+func (c *testClient) addApp(appID, serviceID, name string, attr *ServiceAttr) *Service {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if attr == nil {
+		attr = &ServiceAttr{}
+	}
+	attr.AppID = appID
+	if attr.Status == "" {
+		attr.Status = StatusStopped
+	}
+	service := &Service{
+		ID:         serviceID,
+		Type:       TypeServices,
+		Attributes: attr,
+		Relationships: &ServiceRelationship{
+			App: &RelationshipData{Data: &Relationship{ID: appID, Type: TypeApps}},
+			ServicePlan: &RelationshipData{
+				Data: &Relationship{ID: PlanID(RegionJPTokyo, PlanFree), Type: TypeServicePlans},
+			},
+		},
+	}
+	c.apps[appID] = &App{
+		ID:         appID,
+		Type:       TypeApps,
+		Attributes: &AppAttr{Name: name},
+		Relationships: &AppRelationship{
+			Services: &RelationshipDataList{
+				Data: []*Relationship{{ID: serviceID, Type: TypeServices}},
+			},
+		},
+	}
+	c.services[serviceID] = service
+	return service
+}
+
+// updateAttr modifies attributes of the service directly, without counting calls
+func (c *testClient) updateAttr(serviceID string, f func(attr *ServiceAttr)) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	s := c.services[serviceID]
+	attr := *s.Attributes
+	f(&attr)
+	updated := *s
+	updated.Attributes = &attr
+	c.services[serviceID] = &updated
+}
+
+func (c *testClient) called(method string) int {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.calls[method]
+}
+
+func (c *testClient) enter(method string) error {
+	if c.delay > 0 {
+		time.Sleep(c.delay)
+	}
+	c.mu.Lock()
+	c.calls[method]++
+	return c.errors[method]
+}
+
+func (c *testClient) ListApps() (*AppListData, error) {
+	err := c.enter("ListApps")
+	defer c.mu.Unlock()
+	if err != nil {
+		return nil, err
+	}
+	res := &AppListData{}
+	for _, app := range c.apps {
+		res.Data = append(res.Data, app)
+	}
+	sort.Slice(res.Data, func(i, j int) bool { return res.Data[i].ID < res.Data[j].ID })
+	return res, nil
+}
+
+func (c *testClient) ReadApp(id string) (*AppData, error) {
+	err := c.enter("ReadApp")
+	defer c.mu.Unlock()
+	if err != nil {
+		return nil, err
+	}
+	app, ok := c.apps[id]
+	if !ok {
+		return nil, ErrorNotFound(&httpNotFoundError{url: "/apps/" + id})
+	}
+	res := &AppData{Data: app}
+	for _, s := range app.Relationships.Services.Data {
+		if service, ok := c.services[s.ID]; ok {
+			res.Included = append(res.Included, service)
+		}
+	}
+	return res, nil
+}
+
+func (c *testClient) CreateApp(param *RequestParam) (*AppData, error) {
+	err := c.enter("CreateApp")
+	c.mu.Unlock()
+	if err != nil {
+		return nil, err
+	}
+	if err := param.ValidateForCreate(); err != nil {
+		return nil, err
+	}
+	return c.createApp(param.ToAppSpec()), nil
+}
+
+func (c *testClient) CreateAppWithSpec(spec *AppSpec) (*AppData, error) {
+	err := c.enter("CreateAppWithSpec")
+	c.mu.Unlock()
+	if err != nil {
+		return nil, err
+	}
+	if err := spec.ValidateForCreate(); err != nil {
+		return nil, err
+	}
+	return c.createApp(spec), nil
+}
+
+func (c *testClient) createApp(spec *AppSpec) *AppData {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	appID := uuid.New().String()
+	app := &App{
+		ID:            appID,
+		Type:          TypeApps,
+		Attributes:    &AppAttr{Name: spec.Name},
+		Relationships: &AppRelationship{Services: &RelationshipDataList{}},
+	}
+	res := &AppData{Data: app}
+	for _, s := range spec.Services {
+		serviceID := uuid.New().String()
+		service := &Service{
+			ID:   serviceID,
+			Type: TypeServices,
+			Attributes: &ServiceAttr{
+				AppID:         appID,
+				Image:         s.Image,
+				Command:       s.Command,
+				Instances:     s.Instances,
+				Ports:         s.Ports,
+				Environment:   s.Environment,
+				SubDomain:     s.SubDomain,
+				CustomDomains: CustomDomains(s.CustomDomains...),
+				Status:        StatusStopped,
+			},
+			Relationships: &ServiceRelationship{
+				App:         &RelationshipData{Data: &Relationship{ID: appID, Type: TypeApps}},
+				ServicePlan: NewServiceRelationship(RegionJPTokyo, s.Plan).ServicePlan,
+			},
+		}
+		app.Relationships.Services.Data = append(app.Relationships.Services.Data,
+			&Relationship{ID: serviceID, Type: TypeServices})
+		c.services[serviceID] = service
+		res.Included = append(res.Included, service)
+	}
+	c.apps[appID] = app
+	return res
+}
+
+func (c *testClient) UpdateApp(ctx context.Context, id string, patch AppPatch) (*AppData, error) {
+	err := c.enter("UpdateApp")
+	defer c.mu.Unlock()
+	if err != nil {
+		return nil, err
+	}
+	if err := patch.Validate(); err != nil {
+		return nil, err
+	}
+	app, ok := c.apps[id]
+	if !ok {
+		return nil, ErrorNotFound(fmt.Errorf("app %q is not found", id))
+	}
+	updated := *app
+	updated.Attributes = &AppAttr{Name: *patch.Name}
+	c.apps[id] = &updated
+	return &AppData{Data: &updated}, nil
+}
+
+func (c *testClient) DeleteApp(id string) error {
+	err := c.enter("DeleteApp")
+	defer c.mu.Unlock()
+	if err != nil {
+		return err
+	}
+	app, ok := c.apps[id]
+	if !ok {
+		return ErrorNotFound(fmt.Errorf("app %q is not found", id))
+	}
+	for _, s := range app.Relationships.Services.Data {
+		delete(c.services, s.ID)
+	}
+	delete(c.apps, id)
+	return nil
+}
+
+func (c *testClient) ListServices() (*ServiceListData, error) {
+	err := c.enter("ListServices")
+	defer c.mu.Unlock()
+	if err != nil {
+		return nil, err
+	}
+	res := &ServiceListData{}
+	for _, s := range c.services {
+		res.Data = append(res.Data, s)
+	}
+	sort.Slice(res.Data, func(i, j int) bool { return res.Data[i].ID < res.Data[j].ID })
+	return res, nil
+}
+
+func (c *testClient) ReadService(id string) (*ServiceData, error) {
+	err := c.enter("ReadService")
+	defer c.mu.Unlock()
+	if err != nil {
+		return nil, err
+	}
+	s, ok := c.services[id]
+	if !ok {
+		return nil, ErrorNotFound(&httpNotFoundError{url: "/services/" + id})
+	}
+	return &ServiceData{Data: s}, nil
+}
+
+func (c *testClient) UpdateService(id string, param *RequestParam) (*ServiceData, error) {
+	err := c.enter("UpdateService")
+	defer c.mu.Unlock()
+	if err != nil {
+		return nil, err
+	}
+	if err := param.ValidateForUpdate(); err != nil {
+		return nil, err
+	}
+	s, ok := c.services[id]
+	if !ok {
+		return nil, ErrorNotFound(fmt.Errorf("service %q is not found", id))
+	}
+	attr := *s.Attributes
+	attr.Image = param.Image
+	attr.Command = param.Command
+	attr.Instances = param.Instances
+	attr.Ports = param.Ports
+	attr.Environment = param.Environment
+	attr.SubDomain = param.SubDomain
+	attr.CustomDomains = CustomDomains(param.CustomDomains...)
+	updated := *s
+	updated.Attributes = &attr
+	c.services[id] = &updated
+	return &ServiceData{Data: &updated}, nil
+}
+
+func (c *testClient) PatchService(ctx context.Context, id string, patch *ServicePatch) (*ServiceData, error) {
+	err := c.enter("PatchService")
+	defer c.mu.Unlock()
+	if err != nil {
+		return nil, err
+	}
+	if err := patch.Validate(); err != nil {
+		return nil, err
+	}
+	s, ok := c.services[id]
+	if !ok {
+		return nil, ErrorNotFound(fmt.Errorf("service %q is not found", id))
+	}
+	attr := *s.Attributes
+	if patch.Image != nil {
+		attr.Image = *patch.Image
+	}
+	if patch.Command != nil {
+		attr.Command = *patch.Command
+	}
+	if patch.Instances != nil {
+		attr.Instances = *patch.Instances
+	}
+	if patch.Ports != nil {
+		attr.Ports = patch.Ports
+	}
+	if patch.Environment != nil {
+		attr.Environment = patch.Environment
+	}
+	if patch.SubDomain != nil {
+		attr.SubDomain = *patch.SubDomain
+	}
+	if patch.CustomDomains != nil {
+		attr.CustomDomains = CustomDomains(patch.CustomDomains...)
+	}
+	updated := *s
+	updated.Attributes = &attr
+	c.services[id] = &updated
+	return &ServiceData{Data: &updated}, nil
+}
+
+func (c *testClient) setStatus(method, id, status string) error {
+	err := c.enter(method)
+	defer c.mu.Unlock()
+	if err != nil {
+		return err
+	}
+	s, ok := c.services[id]
+	if !ok {
+		return ErrorNotFound(fmt.Errorf("service %q is not found", id))
+	}
+	attr := *s.Attributes
+	attr.Status = status
+	updated := *s
+	updated.Attributes = &attr
+	c.services[id] = &updated
+	return nil
+}
+
+func (c *testClient) PowerOn(id string) error {
+	return c.setStatus("PowerOn", id, StatusRunning)
+}
+
+func (c *testClient) PowerOff(id string) error {
+	return c.setStatus("PowerOff", id, StatusStopped)
+}
+
+func (c *testClient) WaitForState(ctx context.Context, serviceID string, status string) error {
+	for {
+		s, err := c.ReadService(serviceID)
+		if err != nil {
+			return err
+		}
+		if s.Status() == status {
+			return nil
+		}
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-time.After(10 * time.Millisecond):
+		}
+	}
+}
+
+func (c *testClient) ListPlans(ctx context.Context) ([]*Plan, error) {
+	err := c.enter("ListPlans")
+	defer c.mu.Unlock()
+	if err == nil {
+		err = ctx.Err()
+	}
+	if err != nil {
+		return nil, err
+	}
+	return DefaultPlans, nil
+}
+
+func (c *testClient) Version() string {
+	return Version
+}
@@ -0,0 +1,168 @@
+package arukas
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"text/tabwriter"
+
+	"github.com/hashicorp/go-multierror"
+)
+
+const (
+	// hoursPerMonth is used to calculate hourly cost from monthly price
+	hoursPerMonth = 720
+	// CurrencyJPY represents currency of plan prices
+	CurrencyJPY = "JPY"
+)
+
+// ServiceCost represents estimated cost of a service
+type ServiceCost struct {
+	// ServiceID is empty when estimated from RequestParam
+	ServiceID string `json:"service-id,omitempty"`
+	AppID     string `json:"app-id,omitempty"`
+	// Name is RequestParam.Name, empty when estimated from Service
+	Name        string  `json:"name,omitempty"`
+	PlanID      string  `json:"plan-id"`
+	Instances   int32   `json:"instances"`
+	MonthlyCost float64 `json:"monthly-cost"`
+	HourlyCost  float64 `json:"hourly-cost"`
+}
+
+// label returns a name to identify the service in reports
+func (c *ServiceCost) label() string {
+	switch {
+	case c.Name != "":
+		return c.Name
+	case c.ServiceID != "":
+		return c.ServiceID
+	default:
+		return "-"
+	}
+}
+
+// CostEstimate represents estimated costs of services
+type CostEstimate struct {
+	Services     []*ServiceCost `json:"services"`
+	MonthlyTotal float64        `json:"monthly-total"`
+	HourlyTotal  float64        `json:"hourly-total"`
+	Currency     string         `json:"currency"`
+}
+
+// WriteJSON writes estimate as JSON
+func (e *CostEstimate) WriteJSON(w io.Writer) error {
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	return enc.Encode(e)
+}
+
+// WriteTable writes estimate as human readable table
+func (e *CostEstimate) WriteTable(w io.Writer) error {
+	tw := tabwriter.NewWriter(w, 0, 8, 2, ' ', tabwriter.AlignRight)
+	fmt.Fprintln(tw, "NAME\tPLAN\tINSTANCES\tHOURLY\tMONTHLY\t") // nolint
+	for _, s := range e.Services {
+		fmt.Fprintf(tw, "%s\t%s\t%d\t%.2f\t%.2f\t\n", s.label(), s.PlanID, s.Instances, s.HourlyCost, s.MonthlyCost) // nolint
+	}
+	fmt.Fprintf(tw, "TOTAL(%s)\t\t\t%.2f\t%.2f\t\n", e.Currency, e.HourlyTotal, e.MonthlyTotal) // nolint
+	return tw.Flush()
+}
+
+// CostEstimator estimates costs of services from plan catalog
+type CostEstimator struct {
+	// Plans is a plan catalog. If empty, DefaultPlans is used
+	Plans []*Plan
+}
+
+// EstimateCost estimates monthly/hourly costs of services with DefaultPlans
+func EstimateCost(services []*Service) (*CostEstimate, error) {
+	return (&CostEstimator{}).EstimateCost(services)
+}
+
+// EstimateCostForParams estimates monthly/hourly costs of planned services with DefaultPlans
+func EstimateCostForParams(params []*RequestParam) (*CostEstimate, error) {
+	return (&CostEstimator{}).EstimateCostForParams(params)
+}
+
+// EstimateCost estimates monthly/hourly costs of services.
+// Services with unknown plan are excluded from estimate and reported as error.
+func (e *CostEstimator) EstimateCost(services []*Service) (*CostEstimate, error) {
+	estimate := &CostEstimate{Currency: CurrencyJPY}
+
+	var results error
+	for _, s := range services {
+		if s == nil || s.Attributes == nil {
+			continue
+		}
+		planID := ""
+		if s.hasPlanID() {
+			planID = s.PlanID()
+		}
+		cost, err := e.estimate(planID, s.Instances())
+		if err != nil {
+			results = multierror.Append(results, fmt.Errorf("service %q: %s", s.ID, err))
+			continue
+		}
+		cost.ServiceID = s.ID
+		cost.AppID = s.AppID()
+		estimate.add(cost)
+	}
+	return estimate, results
+}
+
+// EstimateCostForParams estimates monthly/hourly costs of planned services.
+// Params with unknown plan are excluded from estimate and reported as error.
+func (e *CostEstimator) EstimateCostForParams(params []*RequestParam) (*CostEstimate, error) {
+	estimate := &CostEstimate{Currency: CurrencyJPY}
+
+	var results error
+	for _, p := range params {
+		if p == nil {
+			continue
+		}
+		region := p.Region
+		if region == "" {
+			region = RegionJPTokyo
+		}
+		cost, err := e.estimate(PlanID(region, p.Plan), p.Instances)
+		if err != nil {
+			results = multierror.Append(results, fmt.Errorf("param %q: %s", p.Name, err))
+			continue
+		}
+		cost.Name = p.Name
+		estimate.add(cost)
+	}
+	return estimate, results
+}
+
+func (e *CostEstimator) estimate(planID string, instances int32) (*ServiceCost, error) {
+	plan := e.findPlan(planID)
+	if plan == nil {
+		return nil, fmt.Errorf("plan %q is unknown", planID)
+	}
+	monthly := plan.MonthlyPrice * float64(instances)
+	return &ServiceCost{
+		PlanID:      plan.ID,
+		Instances:   instances,
+		MonthlyCost: monthly,
+		HourlyCost:  monthly / hoursPerMonth,
+	}, nil
+}
+
+func (e *CostEstimator) findPlan(id string) *Plan {
+	plans := e.Plans
+	if len(plans) == 0 {
+		plans = DefaultPlans
+	}
+	for _, p := range plans {
+		if p.ID == id {
+			return p
+		}
+	}
+	return nil
+}
+
+func (e *CostEstimate) add(cost *ServiceCost) {
+	e.Services = append(e.Services, cost)
+	e.MonthlyTotal += cost.MonthlyCost
+	e.HourlyTotal += cost.HourlyCost
+}
@@ -0,0 +1,94 @@
+package arukas
+
+import (
+	"bytes"
+	"encoding/json"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestEstimateCost(t *testing.T) {
+	newService := func(id, plan string, instances int32) *Service {
+		return &Service{
+			ID:         id,
+			Attributes: &ServiceAttr{AppID: testAppID, Instances: instances},
+			Relationships: &ServiceRelationship{
+				ServicePlan: &RelationshipData{
+					Data: &Relationship{ID: PlanID(RegionJPTokyo, plan)},
+				},
+			},
+		}
+	}
+
+	t.Run("Services", func(t *testing.T) {
+		estimate, err := EstimateCost([]*Service{
+			newService(testServiceID, PlanHobby, 2),
+			newService(testAnotherSvcID, PlanStandard1, 3),
+		})
+		assert.NoError(t, err)
+		assert.Len(t, estimate.Services, 2)
+		assert.Equal(t, float64(1000), estimate.Services[0].MonthlyCost)
+		assert.Equal(t, float64(3000), estimate.Services[1].MonthlyCost)
+		assert.Equal(t, float64(4000), estimate.MonthlyTotal)
+		assert.InDelta(t, 4000.0/hoursPerMonth, estimate.HourlyTotal, 0.0001)
+	})
+
+	t.Run("Unknown plan", func(t *testing.T) {
+		estimate, err := EstimateCost([]*Service{
+			newService(testServiceID, PlanHobby, 1),
+			newService(testAnotherSvcID, "foobar", 1),
+		})
+		assert.Error(t, err)
+		assert.Len(t, estimate.Services, 1)
+		assert.Equal(t, float64(500), estimate.MonthlyTotal)
+	})
+
+	t.Run("Params", func(t *testing.T) {
+		estimate, err := EstimateCostForParams([]*RequestParam{
+			{Name: "foo", Plan: PlanFree, Instances: 1},
+			{Name: "bar", Plan: PlanStandard2, Instances: 2},
+		})
+		assert.NoError(t, err)
+		assert.Equal(t, "foo", estimate.Services[0].Name)
+		assert.Equal(t, float64(4000), estimate.MonthlyTotal)
+	})
+
+	t.Run("Custom plans", func(t *testing.T) {
+		e := &CostEstimator{
+			Plans: []*Plan{{ID: PlanID(RegionJPTokyo, PlanHobby), MonthlyPrice: 720}},
+		}
+		estimate, err := e.EstimateCostForParams([]*RequestParam{
+			{Name: "foo", Plan: PlanHobby, Instances: 1},
+		})
+		assert.NoError(t, err)
+		assert.Equal(t, float64(1), estimate.HourlyTotal)
+	})
+}
+
+func TestCostEstimate_Write(t *testing.T) {
+	estimate, err := EstimateCostForParams([]*RequestParam{
+		{Name: "foo", Plan: PlanHobby, Instances: 2},
+	})
+	assert.NoError(t, err)
+
+	t.Run("Table", func(t *testing.T) {
+		buf := bytes.NewBufferString("")
+		assert.NoError(t, estimate.WriteTable(buf))
+
+		lines := strings.Split(strings.TrimSpace(buf.String()), "\n")
+		assert.Len(t, lines, 3)
+		assert.Contains(t, lines[1], "jp-tokyo/hobby")
+		assert.Contains(t, lines[2], "1000.00")
+	})
+
+	t.Run("JSON", func(t *testing.T) {
+		buf := bytes.NewBufferString("")
+		assert.NoError(t, estimate.WriteJSON(buf))
+
+		var decoded CostEstimate
+		assert.NoError(t, json.Unmarshal(buf.Bytes(), &decoded))
+		assert.Equal(t, estimate, &decoded)
+	})
+}
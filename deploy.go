@@ -0,0 +1,394 @@
+package arukas
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+
+	"github.com/hashicorp/go-multierror"
+)
+
+const defaultDeploymentParallelism = 4
+
+// DeploymentGate represents a condition which a node must satisfy before its dependents are started
+type DeploymentGate int
+
+const (
+	// GateRunning waits until the service becomes running
+	GateRunning DeploymentGate = iota
+	// GateReachable waits until the service becomes running and all probe targets are reachable
+	GateReachable
+	// GateNone doesn't wait
+	GateNone
+)
+
+// String returns name of the gate
+func (g DeploymentGate) String() string {
+	switch g {
+	case GateRunning:
+		return "running"
+	case GateReachable:
+		return "reachable"
+	case GateNone:
+		return "none"
+	default:
+		return "unknown"
+	}
+}
+
+// DeploymentNode represents an app in a deployment graph.
+// RequestParam.Name is used as the name of the node.
+type DeploymentNode struct {
+	*RequestParam
+	// DependsOn is names of nodes which must pass their gates before this node is started
+	DependsOn []string
+	Gate      DeploymentGate
+	// Probe is used with GateReachable
+	Probe ProbeOptions
+}
+
+// CycleError represents an error that dependencies of deployment nodes have a cycle
+type CycleError struct {
+	// Nodes are names of nodes in the cycle
+	Nodes []string
+}
+
+// Error implements error interface
+func (e *CycleError) Error() string {
+	return fmt.Sprintf("dependency cycle is detected: %s", strings.Join(e.Nodes, " -> "))
+}
+
+// errDependencyFailed is set to results of nodes which are not started because their dependency failed
+var errDependencyFailed = errors.New("dependency failed")
+
+// Deployment represents a graph of apps created and powered on in dependency order
+type Deployment struct {
+	// Parallelism is max number of nodes processed at the same time. default: 4
+	Parallelism int
+	// Mode is passed to EnsureApp when app with same name exists
+	Mode EnsureMode
+
+	nodes []*DeploymentNode
+	index map[string]*DeploymentNode
+}
+
+// DeploymentNodeResult represents result of a node
+type DeploymentNodeResult struct {
+	Name      string
+	AppID     string
+	ServiceID string
+	// Created is true if the app was created by Up
+	Created bool
+	// Skipped is true if the node was not processed because its dependency failed
+	Skipped bool
+	Err     error
+}
+
+// DeploymentResult represents results of all nodes
+type DeploymentResult struct {
+	// Nodes are ordered same as nodes passed to NewDeployment
+	Nodes []*DeploymentNodeResult
+}
+
+// Node returns result of the node, returns nil if not found
+func (r *DeploymentResult) Node(name string) *DeploymentNodeResult {
+	for _, n := range r.Nodes {
+		if n.Name == name {
+			return n
+		}
+	}
+	return nil
+}
+
+// NewDeployment validates nodes and returns new Deployment.
+// *CycleError is returned if dependencies have a cycle.
+func NewDeployment(nodes ...*DeploymentNode) (*Deployment, error) {
+	var results error
+	index := map[string]*DeploymentNode{}
+	for i, n := range nodes {
+		if n == nil || n.RequestParam == nil {
+			results = multierror.Append(results, requiredError(fieldPath("Nodes", i, "RequestParam")))
+			continue
+		}
+		if err := validateAppName(fieldPath("Nodes", i, "Name"), n.Name); err != nil {
+			results = multierror.Append(results, err)
+			continue
+		}
+		if _, ok := index[n.Name]; ok {
+			results = multierror.Append(results, duplicatedError(fieldPath("Nodes", i, "Name")))
+			continue
+		}
+		index[n.Name] = n
+	}
+	if results != nil {
+		return nil, results
+	}
+
+	for i, n := range nodes {
+		for j, dep := range n.DependsOn {
+			if _, ok := index[dep]; !ok {
+				results = multierror.Append(results,
+					newValidationError(fieldPath(fieldPath("Nodes", i, "DependsOn"), j, ""), "node %q is not defined", dep))
+			}
+		}
+	}
+	if results != nil {
+		return nil, results
+	}
+
+	d := &Deployment{nodes: nodes, index: index}
+	if cycle := d.findCycle(); cycle != nil {
+		return nil, &CycleError{Nodes: cycle}
+	}
+	return d, nil
+}
+
+// findCycle returns names of nodes in a cycle, returns nil if no cycle
+func (d *Deployment) findCycle() []string {
+	const (
+		unvisited = iota
+		visiting
+		visited
+	)
+	state := map[string]int{}
+	var stack []string
+
+	var visit func(name string) []string
+	visit = func(name string) []string {
+		state[name] = visiting
+		stack = append(stack, name)
+		for _, dep := range d.index[name].DependsOn {
+			switch state[dep] {
+			case visiting:
+				for i, n := range stack {
+					if n == dep {
+						return append(append([]string{}, stack[i:]...), dep)
+					}
+				}
+			case unvisited:
+				if cycle := visit(dep); cycle != nil {
+					return cycle
+				}
+			}
+		}
+		stack = stack[:len(stack)-1]
+		state[name] = visited
+		return nil
+	}
+
+	for _, n := range d.nodes {
+		if state[n.Name] == unvisited {
+			if cycle := visit(n.Name); cycle != nil {
+				return cycle
+			}
+		}
+	}
+	return nil
+}
+
+// Order returns names of nodes grouped by stage.
+// Nodes in the same stage have no dependency on each other and may run in parallel.
+func (d *Deployment) Order() [][]string {
+	done := map[string]bool{}
+	var stages [][]string
+	for len(done) < len(d.nodes) {
+		var stage []string
+		for _, n := range d.nodes {
+			if done[n.Name] {
+				continue
+			}
+			ready := true
+			for _, dep := range n.DependsOn {
+				if !done[dep] {
+					ready = false
+					break
+				}
+			}
+			if ready {
+				stage = append(stage, n.Name)
+			}
+		}
+		sort.Strings(stage)
+		for _, name := range stage {
+			done[name] = true
+		}
+		stages = append(stages, stage)
+	}
+	return stages
+}
+
+// Up creates and powers on apps in dependency order. Each node is started after all nodes
+// in its DependsOn pass their gates. Service references in params are resolved before creating the app.
+// Nodes depending on a failed node are skipped.
+func (d *Deployment) Up(ctx context.Context, c Client) (*DeploymentResult, error) {
+	return d.run(ctx, false, func(ctx context.Context, n *DeploymentNode, r *DeploymentNodeResult) error {
+		return d.up(ctx, c, n, r)
+	})
+}
+
+// Down powers off apps in reverse dependency order. Each node is stopped after all nodes
+// depending on it are stopped. Apps which are not found are ignored.
+func (d *Deployment) Down(ctx context.Context, c Client) (*DeploymentResult, error) {
+	return d.run(ctx, true, func(ctx context.Context, n *DeploymentNode, r *DeploymentNodeResult) error {
+		return d.down(ctx, c, n, r)
+	})
+}
+
+func (d *Deployment) up(ctx context.Context, c Client, n *DeploymentNode, r *DeploymentNodeResult) error {
+	param := n.RequestParam
+	refs, err := param.ServiceRefs()
+	if err != nil {
+		return err
+	}
+	if len(refs) > 0 {
+		if param, err = ResolveServiceRefs(c, param); err != nil {
+			return err
+		}
+	}
+
+	app, created, err := EnsureApp(ctx, c, param, d.Mode)
+	if err != nil {
+		return err
+	}
+	r.AppID, r.Created = app.Data.ID, created
+
+	service := app.Service()
+	if service == nil {
+		return fmt.Errorf("app %s has no service", app.Data.ID)
+	}
+	r.ServiceID = service.ID
+
+	current, err := c.ReadService(service.ID)
+	if err != nil {
+		return err
+	}
+	if current.Status() != StatusRunning {
+		if err := c.PowerOn(service.ID); err != nil {
+			return err
+		}
+	}
+
+	switch n.Gate {
+	case GateRunning, GateReachable:
+		if err := c.WaitForState(ctx, service.ID, StatusRunning); err != nil {
+			return err
+		}
+	}
+	if n.Gate == GateReachable {
+		s, err := c.ReadService(service.ID)
+		if err != nil {
+			return err
+		}
+		if _, err := WaitForReachable(ctx, s.Data, n.Probe); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (d *Deployment) down(ctx context.Context, c Client, n *DeploymentNode, r *DeploymentNodeResult) error {
+	app, err := FindAppByName(c, n.Name)
+	if err != nil {
+		if _, ok := err.(*NotFoundError); ok {
+			return nil
+		}
+		return err
+	}
+	r.AppID = app.ID
+
+	for _, id := range app.ServiceIDs() {
+		r.ServiceID = id
+		s, err := c.ReadService(id)
+		if err != nil {
+			return err
+		}
+		if s.Status() == StatusStopped {
+			continue
+		}
+		if err := c.PowerOff(id); err != nil {
+			return err
+		}
+		if n.Gate != GateNone {
+			if err := c.WaitForState(ctx, id, StatusStopped); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// run calls fn for each node after its dependencies (or its dependents if reverse) are done
+func (d *Deployment) run(ctx context.Context, reverse bool, fn func(ctx context.Context, n *DeploymentNode, r *DeploymentNodeResult) error) (*DeploymentResult, error) {
+	parallelism := d.Parallelism
+	if parallelism <= 0 {
+		parallelism = defaultDeploymentParallelism
+	}
+
+	waitFor := map[string][]string{}
+	for _, n := range d.nodes {
+		if reverse {
+			for _, dep := range n.DependsOn {
+				waitFor[dep] = append(waitFor[dep], n.Name)
+			}
+		} else {
+			waitFor[n.Name] = n.DependsOn
+		}
+	}
+
+	result := &DeploymentResult{}
+	done := map[string]chan struct{}{}
+	results := map[string]*DeploymentNodeResult{}
+	for _, n := range d.nodes {
+		done[n.Name] = make(chan struct{})
+		results[n.Name] = &DeploymentNodeResult{Name: n.Name}
+		result.Nodes = append(result.Nodes, results[n.Name])
+	}
+
+	sem := make(chan struct{}, parallelism)
+	wg := sync.WaitGroup{}
+	for _, n := range d.nodes {
+		wg.Add(1)
+		go func(n *DeploymentNode) {
+			defer wg.Done()
+			r := results[n.Name]
+			defer close(done[n.Name])
+
+			for _, dep := range waitFor[n.Name] {
+				<-done[dep]
+				if results[dep].Err != nil {
+					r.Skipped, r.Err = true, errDependencyFailed
+					return
+				}
+			}
+
+			select {
+			case sem <- struct{}{}:
+			case <-ctx.Done():
+				r.Skipped, r.Err = true, ctx.Err()
+				return
+			}
+			defer func() { <-sem }()
+
+			if err := ctx.Err(); err != nil {
+				r.Skipped, r.Err = true, err
+				return
+			}
+			r.Err = fn(ctx, n, r)
+		}(n)
+	}
+	wg.Wait()
+
+	var errs error
+	for _, r := range result.Nodes {
+		if r.Err != nil && !r.Skipped {
+			errs = multierror.Append(errs, fmt.Errorf("%s: %s", r.Name, r.Err))
+		}
+	}
+	if errs == nil && ctx.Err() != nil {
+		errs = ctx.Err()
+	}
+	return result, errs
+}
@@ -0,0 +1,166 @@
+package arukas
+
+import (
+	"context"
+	"sync"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func deployTestNode(name string, dependsOn ...string) *DeploymentNode {
+	return &DeploymentNode{RequestParam: testRequestParam(name), DependsOn: dependsOn}
+}
+
+// deployTestClient records order of PowerOn/PowerOff
+type deployTestClient struct {
+	*testClient
+	mu      sync.Mutex
+	powered []string
+}
+
+func (c *deployTestClient) record(id string) {
+	s, _ := c.testClient.ReadService(id)
+	app, _ := c.testClient.ReadApp(s.AppID())
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.powered = append(c.powered, app.Data.Name())
+}
+
+func (c *deployTestClient) PowerOn(id string) error {
+	c.record(id)
+	return c.testClient.PowerOn(id)
+}
+
+func (c *deployTestClient) PowerOff(id string) error {
+	c.record(id)
+	return c.testClient.PowerOff(id)
+}
+
+func TestNewDeployment(t *testing.T) {
+	expects := []struct {
+		scenario string
+		nodes    []*DeploymentNode
+		fields   []string
+		cycle    []string
+	}{
+		{
+			scenario: "Duplicated name",
+			nodes:    []*DeploymentNode{deployTestNode("db"), deployTestNode("db")},
+			fields:   []string{"Nodes[1].Name"},
+		},
+		{
+			scenario: "Undefined dependency",
+			nodes:    []*DeploymentNode{deployTestNode("web", "db", "cache"), deployTestNode("db")},
+			fields:   []string{"Nodes[0].DependsOn[1]"},
+		},
+		{
+			scenario: "Cycle",
+			nodes: []*DeploymentNode{
+				deployTestNode("lb", "web"),
+				deployTestNode("web", "db"),
+				deployTestNode("db", "web"),
+			},
+			cycle: []string{"web", "db", "web"},
+		},
+		{
+			scenario: "Self dependency",
+			nodes:    []*DeploymentNode{deployTestNode("db", "db")},
+			cycle:    []string{"db", "db"},
+		},
+	}
+
+	for _, expect := range expects {
+		t.Run(expect.scenario, func(t *testing.T) {
+			_, err := NewDeployment(expect.nodes...)
+			assert.Error(t, err)
+			if expect.cycle != nil {
+				assert.Equal(t, &CycleError{Nodes: expect.cycle}, err)
+				return
+			}
+			var fields []string
+			for _, e := range ValidationErrors(err) {
+				fields = append(fields, e.Field)
+			}
+			assert.Equal(t, expect.fields, fields)
+		})
+	}
+}
+
+func TestDeployment_Order(t *testing.T) {
+	d, err := NewDeployment(
+		deployTestNode("web", "db", "cache"),
+		deployTestNode("worker", "db"),
+		deployTestNode("cache"),
+		deployTestNode("db"),
+		deployTestNode("lb", "web"),
+	)
+	assert.NoError(t, err)
+	assert.Equal(t, [][]string{{"cache", "db"}, {"web", "worker"}, {"lb"}}, d.Order())
+}
+
+func TestDeployment_UpDown(t *testing.T) {
+	ctx := context.Background()
+
+	t.Run("Dependency order", func(t *testing.T) {
+		c := &deployTestClient{testClient: newTestClient()}
+		d, err := NewDeployment(
+			deployTestNode("lb", "web"),
+			deployTestNode("web", "db"),
+			deployTestNode("db"),
+		)
+		assert.NoError(t, err)
+
+		res, err := d.Up(ctx, c)
+		assert.NoError(t, err)
+		assert.Equal(t, []string{"db", "web", "lb"}, c.powered)
+		for _, n := range res.Nodes {
+			assert.True(t, n.Created)
+			s, _ := c.ReadService(n.ServiceID)
+			assert.Equal(t, StatusRunning, s.Status())
+		}
+
+		// already running
+		res, err = d.Up(ctx, c)
+		assert.NoError(t, err)
+		assert.False(t, res.Node("db").Created)
+		assert.Equal(t, 3, c.called("PowerOn"))
+
+		c.powered = nil
+		_, err = d.Down(ctx, c)
+		assert.NoError(t, err)
+		assert.Equal(t, []string{"lb", "web", "db"}, c.powered)
+		for _, n := range res.Nodes {
+			s, _ := c.ReadService(n.ServiceID)
+			assert.Equal(t, StatusStopped, s.Status())
+		}
+	})
+
+	t.Run("Failed dependency", func(t *testing.T) {
+		c := &deployTestClient{testClient: newTestClient()}
+		c.addApp(testAppID, testServiceID, "db", &ServiceAttr{Image: "postgres", Instances: 1})
+		d, err := NewDeployment(
+			deployTestNode("web", "db"),
+			deployTestNode("db"),
+			deployTestNode("cache"),
+		)
+		assert.NoError(t, err)
+
+		res, err := d.Up(ctx, c)
+		assert.Error(t, err)
+		assert.IsType(t, &SpecMismatchError{}, res.Node("db").Err)
+		assert.True(t, res.Node("web").Skipped)
+		assert.Empty(t, res.Node("web").AppID)
+		assert.NoError(t, res.Node("cache").Err)
+	})
+
+	t.Run("Down ignores missing apps", func(t *testing.T) {
+		c := &deployTestClient{testClient: newTestClient()}
+		d, err := NewDeployment(deployTestNode("web", "db"), deployTestNode("db"))
+		assert.NoError(t, err)
+
+		_, err = d.Down(ctx, c)
+		assert.NoError(t, err)
+		assert.Empty(t, c.powered)
+	})
+}
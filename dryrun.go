@@ -0,0 +1,641 @@
+package arukas
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// Snapshot represents apps and services captured at a point in time.
+// It can be used as a source of DryRunClient without API access.
+type Snapshot struct {
+	Apps     []*App     `json:"apps"`
+	Services []*Service `json:"services"`
+	Plans    []*Plan    `json:"plans,omitempty"`
+}
+
+// TakeSnapshot returns *Snapshot of all apps and services
+func TakeSnapshot(ctx context.Context, c Client) (*Snapshot, error) {
+	apps, err := c.ListApps()
+	if err != nil {
+		return nil, err
+	}
+	services, err := c.ListServices()
+	if err != nil {
+		return nil, err
+	}
+	plans, err := c.ListPlans(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return &Snapshot{Apps: apps.Data, Services: services.Data, Plans: plans}, nil
+}
+
+// ReadSnapshot reads *Snapshot written by Snapshot.WriteJSON
+func ReadSnapshot(r io.Reader) (*Snapshot, error) {
+	var s Snapshot
+	if err := json.NewDecoder(r).Decode(&s); err != nil {
+		return nil, err
+	}
+	return &s, nil
+}
+
+// WriteJSON writes snapshot as JSON
+func (s *Snapshot) WriteJSON(w io.Writer) error {
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	return enc.Encode(s)
+}
+
+// dryRunSource is a source of reads of DryRunClient
+type dryRunSource interface {
+	ListApps() (*AppListData, error)
+	ReadApp(id string) (*AppData, error)
+	ListServices() (*ServiceListData, error)
+	ReadService(id string) (*ServiceData, error)
+	ListPlans(ctx context.Context) ([]*Plan, error)
+	Version() string
+}
+
+// snapshotSource implements dryRunSource with *Snapshot
+type snapshotSource struct {
+	snapshot *Snapshot
+}
+
+func (s *snapshotSource) ListApps() (*AppListData, error) {
+	return &AppListData{Data: s.snapshot.Apps}, nil
+}
+
+func (s *snapshotSource) ReadApp(id string) (*AppData, error) {
+	for _, app := range s.snapshot.Apps {
+		if app.ID != id {
+			continue
+		}
+		res := &AppData{Data: app}
+		for _, serviceID := range app.ServiceIDs() {
+			if service, err := s.ReadService(serviceID); err == nil {
+				res.Included = append(res.Included, service.Data)
+			}
+		}
+		return res, nil
+	}
+	return nil, ErrorNotFound(fmt.Errorf("app %q is not found in snapshot", id))
+}
+
+func (s *snapshotSource) ListServices() (*ServiceListData, error) {
+	return &ServiceListData{Data: s.snapshot.Services}, nil
+}
+
+func (s *snapshotSource) ReadService(id string) (*ServiceData, error) {
+	for _, service := range s.snapshot.Services {
+		if service.ID == id {
+			return &ServiceData{Data: service}, nil
+		}
+	}
+	return nil, ErrorNotFound(fmt.Errorf("service %q is not found in snapshot", id))
+}
+
+func (s *snapshotSource) ListPlans(ctx context.Context) ([]*Plan, error) {
+	if len(s.snapshot.Plans) == 0 {
+		return defaultPlans(), nil
+	}
+	return s.snapshot.Plans, nil
+}
+
+func (s *snapshotSource) Version() string {
+	return Version
+}
+
+// PlannedOperation represents a mutation recorded by DryRunClient
+type PlannedOperation struct {
+	// Method is name of Client method. e.g. "CreateApp"
+	Method string `json:"method"`
+	// ID is ID of target app or service, ID assigned by DryRunClient for CreateApp/CreateAppWithSpec
+	ID string `json:"id"`
+	// Param is *RequestParam, *AppSpec, *AppPatch or *ServicePatch passed to the method
+	Param interface{} `json:"param,omitempty"`
+	// Warnings are problems which don't fail the real Client. e.g. duplicated app name on create
+	Warnings []string `json:"warnings,omitempty"`
+}
+
+// String returns human readable operation
+func (o *PlannedOperation) String() string {
+	str := fmt.Sprintf("%s %s", o.Method, o.ID)
+	if o.Param != nil {
+		if param, err := json.Marshal(o.Param); err == nil {
+			str = fmt.Sprintf("%s %s", str, param)
+		}
+	}
+	for _, w := range o.Warnings {
+		str = fmt.Sprintf("%s (warning: %s)", str, w)
+	}
+	return str
+}
+
+// PlannedOperations represents mutations recorded by DryRunClient
+type PlannedOperations []*PlannedOperation
+
+// WriteJSON writes operations as JSON
+func (ops PlannedOperations) WriteJSON(w io.Writer) error {
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	return enc.Encode(ops)
+}
+
+// WriteText writes operations as numbered lines
+func (ops PlannedOperations) WriteText(w io.Writer) error {
+	for i, op := range ops {
+		if _, err := fmt.Fprintf(w, "%d. %s\n", i+1, op); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// DryRunClient implements Client without mutating resources.
+//
+// Reads are delegated to a real Client or a Snapshot.
+// Mutations are validated, simulated in an in-memory overlay so later reads see them,
+// and recorded as PlannedOperations.
+type DryRunClient struct {
+	source dryRunSource
+
+	mu         sync.Mutex
+	apps       map[string]*App
+	services   map[string]*Service
+	deleted    map[string]bool
+	created    []string
+	operations PlannedOperations
+}
+
+// NewDryRunClient returns a new DryRunClient which reads from c
+func NewDryRunClient(c Client) *DryRunClient {
+	return newDryRunClient(c)
+}
+
+// NewDryRunClientFromSnapshot returns a new DryRunClient which reads from s
+func NewDryRunClientFromSnapshot(s *Snapshot) *DryRunClient {
+	return newDryRunClient(&snapshotSource{snapshot: s})
+}
+
+func newDryRunClient(source dryRunSource) *DryRunClient {
+	return &DryRunClient{
+		source:   source,
+		apps:     map[string]*App{},
+		services: map[string]*Service{},
+		deleted:  map[string]bool{},
+	}
+}
+
+// Operations returns mutations recorded so far
+func (c *DryRunClient) Operations() PlannedOperations {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return append(PlannedOperations{}, c.operations...)
+}
+
+// Reset discards the overlay and recorded operations
+func (c *DryRunClient) Reset() {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.apps = map[string]*App{}
+	c.services = map[string]*Service{}
+	c.deleted = map[string]bool{}
+	c.created = nil
+	c.operations = nil
+}
+
+func (c *DryRunClient) record(method, id string, param interface{}, warnings ...string) {
+	c.operations = append(c.operations, &PlannedOperation{Method: method, ID: id, Param: param, Warnings: warnings})
+}
+
+// ListApps implements Client interface
+func (c *DryRunClient) ListApps() (*AppListData, error) {
+	list, err := c.source.ListApps()
+	if err != nil {
+		return nil, err
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	res := &AppListData{}
+	for _, app := range list.Data {
+		if c.deleted[app.ID] {
+			continue
+		}
+		if overlay, ok := c.apps[app.ID]; ok {
+			app = overlay
+		}
+		res.Data = append(res.Data, app)
+	}
+	for _, id := range c.created {
+		if app, ok := c.apps[id]; ok && !c.deleted[id] {
+			res.Data = append(res.Data, app)
+		}
+	}
+	return res, nil
+}
+
+// ReadApp implements Client interface
+func (c *DryRunClient) ReadApp(id string) (*AppData, error) {
+	if err := validateID("ID", id); err != nil {
+		return nil, err
+	}
+
+	c.mu.Lock()
+	if c.deleted[id] {
+		c.mu.Unlock()
+		return nil, ErrorNotFound(fmt.Errorf("app %q is deleted in dry-run", id))
+	}
+	app, ok := c.apps[id]
+	c.mu.Unlock()
+
+	if !ok {
+		data, err := c.source.ReadApp(id)
+		if err != nil {
+			return nil, err
+		}
+		app = data.Data
+	}
+
+	res := &AppData{Data: app}
+	for _, serviceID := range app.ServiceIDs() {
+		s, err := c.ReadService(serviceID)
+		if err != nil {
+			return nil, err
+		}
+		res.Included = append(res.Included, s.Data)
+	}
+	return res, nil
+}
+
+// CreateApp implements Client interface
+func (c *DryRunClient) CreateApp(param *RequestParam) (*AppData, error) {
+	if param == nil {
+		return nil, errors.New("param is nil")
+	}
+	if err := param.ValidateForCreate(); err != nil {
+		return nil, err
+	}
+	return c.createApp("CreateApp", param.ToAppSpec(), param)
+}
+
+// CreateAppWithSpec implements Client interface
+func (c *DryRunClient) CreateAppWithSpec(spec *AppSpec) (*AppData, error) {
+	if spec == nil {
+		return nil, errors.New("spec is nil")
+	}
+	if err := spec.ValidateForCreate(); err != nil {
+		return nil, err
+	}
+	return c.createApp("CreateAppWithSpec", spec, spec)
+}
+
+func (c *DryRunClient) createApp(method string, spec *AppSpec, param interface{}) (*AppData, error) {
+	// the real Client doesn't check uniqueness on create, so a duplicated name is only a warning
+	var warnings []string
+	if err := c.checkAppName("", spec.Name); err != nil {
+		if _, ok := err.(*ValidationError); !ok {
+			return nil, err
+		}
+		warnings = append(warnings, err.Error())
+	}
+
+	now := time.Now()
+	appID := uuid.New().String()
+	app := &App{
+		ID:            appID,
+		Type:          TypeApps,
+		Attributes:    &AppAttr{Name: spec.Name, CreatedAt: &now, UpdatedAt: &now},
+		Relationships: &AppRelationship{Services: &RelationshipDataList{}},
+	}
+	res := &AppData{Data: app}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	for i := range spec.Services {
+		param := spec.Services[i].requestParam(spec.Name)
+		service := &Service{
+			ID:   uuid.New().String(),
+			Type: TypeServices,
+			Attributes: &ServiceAttr{
+				AppID:     appID,
+				Status:    StatusStopped,
+				CreatedAt: &now,
+			},
+			Relationships: &ServiceRelationship{
+				App: &RelationshipData{Data: &Relationship{ID: appID, Type: TypeApps}},
+			},
+		}
+		applyRequestParam(service, param)
+		app.Relationships.Services.Data = append(app.Relationships.Services.Data,
+			&Relationship{ID: service.ID, Type: TypeServices})
+		c.services[service.ID] = service
+		res.Included = append(res.Included, service)
+	}
+	c.apps[appID] = app
+	c.created = append(c.created, appID)
+	c.record(method, appID, param, warnings...)
+	return res, nil
+}
+
+// checkAppName returns error if other app has the name
+func (c *DryRunClient) checkAppName(id, name string) error {
+	apps, err := c.ListApps()
+	if err != nil {
+		return err
+	}
+	for _, app := range apps.Data {
+		if app.ID != id && app.Attributes != nil && app.Name() == name {
+			return appNameConflictError("Name", app.ID)
+		}
+	}
+	return nil
+}
+
+// UpdateApp implements Client interface
+func (c *DryRunClient) UpdateApp(ctx context.Context, id string, patch AppPatch) (*AppData, error) {
+	if err := validateRequired("ID", id); err != nil {
+		return nil, err
+	}
+	if err := validateID("ID", id); err != nil {
+		return nil, err
+	}
+	if err := patch.Validate(); err != nil {
+		return nil, err
+	}
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	if err := c.checkAppName(id, *patch.Name); err != nil {
+		return nil, err
+	}
+
+	current, err := c.ReadApp(id)
+	if err != nil {
+		return nil, err
+	}
+	updated := *current.Data
+	attr := AppAttr{}
+	if updated.Attributes != nil {
+		attr = *updated.Attributes
+	}
+	now := time.Now()
+	attr.Name, attr.UpdatedAt = *patch.Name, &now
+	updated.Attributes = &attr
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.apps[id] = &updated
+	c.record("UpdateApp", id, &patch)
+	return &AppData{Data: &updated}, nil
+}
+
+// DeleteApp implements Client interface
+func (c *DryRunClient) DeleteApp(id string) error {
+	if err := validateID("ID", id); err != nil {
+		return err
+	}
+	current, err := c.ReadApp(id)
+	if err != nil {
+		return err
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.deleted[id] = true
+	for _, serviceID := range current.Data.ServiceIDs() {
+		c.deleted[serviceID] = true
+	}
+	c.record("DeleteApp", id, nil)
+	return nil
+}
+
+// ListServices implements Client interface
+func (c *DryRunClient) ListServices() (*ServiceListData, error) {
+	list, err := c.source.ListServices()
+	if err != nil {
+		return nil, err
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	res := &ServiceListData{}
+	seen := map[string]bool{}
+	for _, s := range list.Data {
+		seen[s.ID] = true
+		if c.deleted[s.ID] {
+			continue
+		}
+		if overlay, ok := c.services[s.ID]; ok {
+			s = overlay
+		}
+		res.Data = append(res.Data, s)
+	}
+	var created []*Service
+	for id, s := range c.services {
+		if !seen[id] && !c.deleted[id] {
+			created = append(created, s)
+		}
+	}
+	sort.Slice(created, func(i, j int) bool { return created[i].ID < created[j].ID })
+	res.Data = append(res.Data, created...)
+	return res, nil
+}
+
+// ReadService implements Client interface
+func (c *DryRunClient) ReadService(id string) (*ServiceData, error) {
+	if err := validateID("ID", id); err != nil {
+		return nil, err
+	}
+
+	c.mu.Lock()
+	if c.deleted[id] {
+		c.mu.Unlock()
+		return nil, ErrorNotFound(fmt.Errorf("service %q is deleted in dry-run", id))
+	}
+	s, ok := c.services[id]
+	c.mu.Unlock()
+
+	if ok {
+		return &ServiceData{Data: s}, nil
+	}
+	return c.source.ReadService(id)
+}
+
+// UpdateService implements Client interface
+func (c *DryRunClient) UpdateService(id string, param *RequestParam) (*ServiceData, error) {
+	if err := validateID("ID", id); err != nil {
+		return nil, err
+	}
+	if param == nil {
+		return nil, errors.New("param is nil")
+	}
+	if err := param.ValidateForUpdate(); err != nil {
+		return nil, err
+	}
+	return c.updateService("UpdateService", id, param, func(s *Service) {
+		applyRequestParam(s, param)
+	})
+}
+
+// PatchService implements Client interface
+func (c *DryRunClient) PatchService(ctx context.Context, id string, patch *ServicePatch) (*ServiceData, error) {
+	if err := validateID("ID", id); err != nil {
+		return nil, err
+	}
+	if patch == nil {
+		return nil, errors.New("patch is nil")
+	}
+	if err := patch.Validate(); err != nil {
+		return nil, err
+	}
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	return c.updateService("PatchService", id, patch, func(s *Service) {
+		applyServicePatch(s, patch)
+	})
+}
+
+// PowerOn implements Client interface
+func (c *DryRunClient) PowerOn(id string) error {
+	if err := validateID("ID", id); err != nil {
+		return err
+	}
+	_, err := c.updateService("PowerOn", id, nil, func(s *Service) {
+		s.Attributes.Status = StatusRunning
+	})
+	return err
+}
+
+// PowerOff implements Client interface
+func (c *DryRunClient) PowerOff(id string) error {
+	if err := validateID("ID", id); err != nil {
+		return err
+	}
+	_, err := c.updateService("PowerOff", id, nil, func(s *Service) {
+		s.Attributes.Status = StatusStopped
+	})
+	return err
+}
+
+// updateService applies f to a copy of the service and stores it in overlay
+func (c *DryRunClient) updateService(method, id string, param interface{}, f func(s *Service)) (*ServiceData, error) {
+	current, err := c.ReadService(id)
+	if err != nil {
+		return nil, err
+	}
+	updated := *current.Data
+	attr := ServiceAttr{}
+	if updated.Attributes != nil {
+		attr = *updated.Attributes
+	}
+	now := time.Now()
+	attr.UpdatedAt = &now
+	updated.Attributes = &attr
+	f(&updated)
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.services[id] = &updated
+	c.record(method, id, param)
+	return &ServiceData{Data: &updated}, nil
+}
+
+// WaitForState implements Client interface.
+// Status is not changed during dry-run, so it returns error immediately if the status doesn't match.
+func (c *DryRunClient) WaitForState(ctx context.Context, serviceID string, status string) error {
+	if err := validateID("ServiceID", serviceID); err != nil {
+		return err
+	}
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	s, err := c.ReadService(serviceID)
+	if err != nil {
+		return err
+	}
+	if s.Status() != status {
+		return fmt.Errorf("service %s is %s, it never becomes %s in dry-run", serviceID, s.Status(), status)
+	}
+	return nil
+}
+
+// ListPlans implements Client interface
+func (c *DryRunClient) ListPlans(ctx context.Context) ([]*Plan, error) {
+	return c.source.ListPlans(ctx)
+}
+
+// Version implements Client interface
+func (c *DryRunClient) Version() string {
+	return c.source.Version()
+}
+
+// applyRequestParam sets attributes and plan of the service from param
+func applyRequestParam(s *Service, p *RequestParam) {
+	s.Attributes.Image = p.Image
+	s.Attributes.Command = p.Command
+	s.Attributes.Instances = p.Instances
+	s.Attributes.Ports = p.Ports
+	s.Attributes.Environment = p.Environment
+	s.Attributes.SubDomain = p.SubDomain
+	s.Attributes.CustomDomains = CustomDomains(p.CustomDomains...)
+	if p.Plan != "" {
+		setServicePlan(s, p.Region, p.Plan)
+	}
+}
+
+// applyServicePatch sets specified attributes and plan of the service from patch
+func applyServicePatch(s *Service, p *ServicePatch) {
+	if p.Image != nil {
+		s.Attributes.Image = *p.Image
+	}
+	if p.Command != nil {
+		s.Attributes.Command = *p.Command
+	}
+	if p.Instances != nil {
+		s.Attributes.Instances = *p.Instances
+	}
+	if p.Ports != nil {
+		s.Attributes.Ports = p.Ports
+	}
+	if p.Environment != nil {
+		s.Attributes.Environment = p.Environment
+	}
+	if p.SubDomain != nil {
+		s.Attributes.SubDomain = *p.SubDomain
+	}
+	if p.CustomDomains != nil {
+		s.Attributes.CustomDomains = CustomDomains(p.CustomDomains...)
+	}
+	if p.Plan != nil {
+		region := ""
+		if p.Region != nil {
+			region = *p.Region
+		}
+		setServicePlan(s, region, *p.Plan)
+	}
+}
+
+func setServicePlan(s *Service, region, plan string) {
+	if region == "" {
+		region = RegionJPTokyo
+	}
+	relationships := ServiceRelationship{}
+	if s.Relationships != nil {
+		relationships = *s.Relationships
+	}
+	relationships.ServicePlan = NewServiceRelationship(region, plan).ServicePlan
+	s.Relationships = &relationships
+}
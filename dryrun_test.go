@@ -0,0 +1,121 @@
+package arukas
+
+import (
+	"bytes"
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestDryRunClient(t *testing.T) {
+	ctx := context.Background()
+
+	t.Run("Mutations are simulated", func(t *testing.T) {
+		base := newTestClient()
+		base.addApp(testAppID, testServiceID, "foo", &ServiceAttr{Image: "nginx:1", Instances: 1})
+		c := NewDryRunClient(base)
+
+		app, err := c.CreateApp(testRequestParam("bar"))
+		assert.NoError(t, err)
+		serviceID := app.Service().ID
+
+		apps, err := c.ListApps()
+		assert.NoError(t, err)
+		assert.Len(t, apps.Data, 2)
+		assert.NoError(t, c.PowerOn(serviceID))
+		assert.NoError(t, c.WaitForState(ctx, serviceID, StatusRunning))
+
+		_, err = c.PatchService(ctx, testServiceID, &ServicePatch{Image: String("nginx:2")})
+		assert.NoError(t, err)
+		s, err := c.ReadService(testServiceID)
+		assert.NoError(t, err)
+		assert.Equal(t, "nginx:2", s.Image())
+
+		assert.NoError(t, c.DeleteApp(testAppID))
+		_, err = c.ReadApp(testAppID)
+		assert.Error(t, err)
+		services, err := c.ListServices()
+		assert.NoError(t, err)
+		assert.Len(t, services.Data, 1)
+		assert.Equal(t, serviceID, services.Data[0].ID)
+
+		// base is not touched
+		for _, method := range []string{"CreateApp", "PowerOn", "PatchService", "DeleteApp"} {
+			assert.Equal(t, 0, base.called(method), method)
+		}
+		baseService, _ := base.ReadService(testServiceID)
+		assert.Equal(t, "nginx:1", baseService.Image())
+
+		ops := c.Operations()
+		var methods []string
+		for _, op := range ops {
+			methods = append(methods, op.Method)
+		}
+		assert.Equal(t, []string{"CreateApp", "PowerOn", "PatchService", "DeleteApp"}, methods)
+		assert.Equal(t, app.Data.ID, ops[0].ID)
+
+		buf := bytes.NewBufferString("")
+		assert.NoError(t, ops.WriteText(buf))
+		assert.Contains(t, buf.String(), "4. DeleteApp "+testAppID)
+
+		c.Reset()
+		assert.Empty(t, c.Operations())
+		s, _ = c.ReadService(testServiceID)
+		assert.Equal(t, "nginx:1", s.Image())
+	})
+
+	t.Run("Mutations are validated", func(t *testing.T) {
+		base := newTestClient()
+		base.addApp(testAppID, testServiceID, "foo", &ServiceAttr{Image: "nginx", Instances: 1})
+		c := NewDryRunClient(base)
+
+		_, err := c.CreateApp(&RequestParam{Name: "bar"})
+		assert.Error(t, err)
+		_, err = c.UpdateService(testServiceID, &RequestParam{Image: "nginx"})
+		assert.Error(t, err)
+		assert.Error(t, c.WaitForState(ctx, testServiceID, StatusRunning))
+		assert.Empty(t, c.Operations())
+	})
+
+	t.Run("Duplicated app name is a warning", func(t *testing.T) {
+		base := newTestClient()
+		base.addApp(testAppID, testServiceID, "foo", &ServiceAttr{Image: "nginx", Instances: 1})
+		c := NewDryRunClient(base)
+
+		_, err := c.CreateApp(testRequestParam("foo"))
+		assert.NoError(t, err)
+		ops := c.Operations()
+		assert.Len(t, ops, 1)
+		assert.Len(t, ops[0].Warnings, 1)
+		assert.Contains(t, ops[0].String(), "warning: ")
+	})
+
+	t.Run("Snapshot", func(t *testing.T) {
+		base := newTestClient()
+		base.addApp(testAppID, testServiceID, "foo", &ServiceAttr{Image: "nginx", Instances: 1})
+		snapshot, err := TakeSnapshot(ctx, base)
+		assert.NoError(t, err)
+
+		buf := bytes.NewBufferString("")
+		assert.NoError(t, snapshot.WriteJSON(buf))
+		snapshot, err = ReadSnapshot(buf)
+		assert.NoError(t, err)
+
+		c := NewDryRunClientFromSnapshot(snapshot)
+		app, err := c.ReadApp(testAppID)
+		assert.NoError(t, err)
+		assert.Equal(t, "foo", app.Data.Name())
+		assert.Equal(t, testServiceID, app.Service().ID)
+
+		_, err = c.UpdateApp(ctx, testAppID, AppPatch{Name: String("bar")})
+		assert.NoError(t, err)
+		found, err := FindAppByName(c, "bar")
+		assert.NoError(t, err)
+		assert.Equal(t, testAppID, found.ID)
+
+		buf.Reset()
+		assert.NoError(t, c.Operations().WriteJSON(buf))
+		assert.Contains(t, buf.String(), `"method": "UpdateApp"`)
+	})
+}
@@ -0,0 +1,170 @@
+package arukas
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// EnsureMode represents how EnsureApp treats existing app which differs from requested spec
+type EnsureMode int
+
+const (
+	// EnsureFailOnMismatch returns *SpecMismatchError if existing app differs from requested spec
+	EnsureFailOnMismatch EnsureMode = iota
+	// EnsureKeepExisting returns existing app as is even if it differs from requested spec
+	EnsureKeepExisting
+	// EnsureUpdateExisting updates the service of existing app to requested spec
+	EnsureUpdateExisting
+)
+
+// SpecMismatchError represents an error that existing app differs from requested spec
+type SpecMismatchError struct {
+	AppID string
+	// Fields are names of RequestParam fields which differ
+	Fields []string
+}
+
+// Error implements error interface
+func (e *SpecMismatchError) Error() string {
+	return fmt.Sprintf("app %s differs from requested spec: [%s]", e.AppID, strings.Join(e.Fields, ", "))
+}
+
+// EnsureApp creates app if the app with same name doesn't exist, otherwise returns existing app.
+// It is safe to retry after CreateApp failed by timeout.
+// mode controls how existing app which differs from param is treated.
+// created is true if the app was created.
+func EnsureApp(ctx context.Context, c Client, param *RequestParam, mode EnsureMode) (app *AppData, created bool, err error) {
+	if param == nil {
+		return nil, false, errors.New("param is nil")
+	}
+	if err := param.ValidateForCreate(); err != nil {
+		return nil, false, err
+	}
+
+	found, err := FindAppByName(c, param.Name)
+	if err != nil {
+		if _, ok := err.(*NotFoundError); !ok {
+			return nil, false, err
+		}
+		if err := ctx.Err(); err != nil {
+			return nil, false, err
+		}
+		app, err := c.CreateApp(param)
+		if err != nil {
+			return nil, false, err
+		}
+		return app, true, nil
+	}
+
+	if err := ctx.Err(); err != nil {
+		return nil, false, err
+	}
+	existing, err := c.ReadApp(found.ID)
+	if err != nil {
+		return nil, false, err
+	}
+	service := existing.Service()
+	if service == nil || service.Attributes == nil {
+		return nil, false, fmt.Errorf("app %s has no service", found.ID)
+	}
+
+	fields := diffServiceSpec(service, param)
+	if len(fields) == 0 || mode == EnsureKeepExisting {
+		return existing, false, nil
+	}
+	if mode != EnsureUpdateExisting {
+		return nil, false, &SpecMismatchError{AppID: found.ID, Fields: fields}
+	}
+
+	if err := ctx.Err(); err != nil {
+		return nil, false, err
+	}
+	if _, err := c.UpdateService(service.ID, param); err != nil {
+		return nil, false, err
+	}
+	app, err = c.ReadApp(found.ID)
+	if err != nil {
+		return nil, false, err
+	}
+	return app, false, nil
+}
+
+// diffServiceSpec returns names of RequestParam fields which differ from the service.
+// Optional fields which are empty in param are not compared.
+func diffServiceSpec(s *Service, p *RequestParam) []string {
+	return diffRequestParam(s.ToRequestParam(), p)
+}
+
+// diffRequestParam returns names of fields of desired which differ from current.
+// SubDomain and Plan are compared only when they are set in desired.
+func diffRequestParam(current, desired *RequestParam) []string {
+	var fields []string
+
+	if current.Image != desired.Image {
+		fields = append(fields, "Image")
+	}
+	if current.Command != desired.Command {
+		fields = append(fields, "Command")
+	}
+	if current.Instances != desired.Instances {
+		fields = append(fields, "Instances")
+	}
+	if !equalStrings(portStrings(current.Ports), portStrings(desired.Ports)) {
+		fields = append(fields, "Ports")
+	}
+	if !equalStrings(envStrings(current.Environment), envStrings(desired.Environment)) {
+		fields = append(fields, "Environment")
+	}
+	if desired.SubDomain != "" && current.SubDomain != desired.SubDomain {
+		fields = append(fields, "SubDomain")
+	}
+	if !equalStrings(current.CustomDomains, desired.CustomDomains) {
+		fields = append(fields, "CustomDomains")
+	}
+	if desired.Plan != "" && current.Plan != "" {
+		region := desired.Region
+		if region == "" {
+			region = RegionJPTokyo
+		}
+		if PlanID(current.Region, current.Plan) != PlanID(region, desired.Plan) {
+			fields = append(fields, "Plan")
+		}
+	}
+	return fields
+}
+
+func portStrings(ports Ports) []string {
+	var res []string
+	for _, p := range ports {
+		res = append(res, fmt.Sprintf("%d/%s", p.Number, p.Protocol))
+	}
+	return res
+}
+
+func envStrings(envs []*Env) []string {
+	var res []string
+	for _, e := range envs {
+		res = append(res, e.Key+"="+e.Value)
+	}
+	return res
+}
+
+// equalStrings returns true if a and b have same elements regardless of order
+func equalStrings(a, b []string) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	a = append([]string{}, a...)
+	b = append([]string{}, b...)
+	sort.Strings(a)
+	sort.Strings(b)
+	for i := range a {
+		if a[i] != b[i] {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,79 @@
+package arukas
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestEnsureApp(t *testing.T) {
+	ctx := context.Background()
+
+	existingAttr := func() *ServiceAttr {
+		return &ServiceAttr{
+			Image:     validCreateAppParam.Image,
+			Instances: validCreateAppParam.Instances,
+			Ports:     validCreateAppParam.Ports,
+		}
+	}
+
+	t.Run("Create if not exists", func(t *testing.T) {
+		c := newTestClient()
+
+		app, created, err := EnsureApp(ctx, c, validCreateAppParam, EnsureFailOnMismatch)
+		assert.NoError(t, err)
+		assert.True(t, created)
+		assert.Equal(t, validCreateAppParam.Name, app.Name())
+		assert.Equal(t, 1, c.called("CreateApp"))
+	})
+
+	t.Run("Return existing app with same spec", func(t *testing.T) {
+		c := newTestClient()
+		c.addApp(testAppID, testServiceID, validCreateAppParam.Name, existingAttr())
+
+		app, created, err := EnsureApp(ctx, c, validCreateAppParam, EnsureFailOnMismatch)
+		assert.NoError(t, err)
+		assert.False(t, created)
+		assert.Equal(t, testAppID, app.AppID())
+		assert.Equal(t, 0, c.called("CreateApp"))
+	})
+
+	t.Run("Fail on mismatch", func(t *testing.T) {
+		c := newTestClient()
+		attr := existingAttr()
+		attr.Image = "httpd:latest"
+		c.addApp(testAppID, testServiceID, validCreateAppParam.Name, attr)
+
+		app, _, err := EnsureApp(ctx, c, validCreateAppParam, EnsureFailOnMismatch)
+		assert.Nil(t, app)
+		assert.IsType(t, &SpecMismatchError{}, err)
+		assert.Equal(t, []string{"Image"}, err.(*SpecMismatchError).Fields)
+	})
+
+	t.Run("Keep existing on mismatch", func(t *testing.T) {
+		c := newTestClient()
+		attr := existingAttr()
+		attr.Instances = 2
+		c.addApp(testAppID, testServiceID, validCreateAppParam.Name, attr)
+
+		app, created, err := EnsureApp(ctx, c, validCreateAppParam, EnsureKeepExisting)
+		assert.NoError(t, err)
+		assert.False(t, created)
+		assert.Equal(t, int32(2), app.Service().Instances())
+		assert.Equal(t, 0, c.called("UpdateService"))
+	})
+
+	t.Run("Update existing on mismatch", func(t *testing.T) {
+		c := newTestClient()
+		attr := existingAttr()
+		attr.Environment = []*Env{{Key: "FOO", Value: "bar"}}
+		c.addApp(testAppID, testServiceID, validCreateAppParam.Name, attr)
+
+		app, created, err := EnsureApp(ctx, c, validCreateAppParam, EnsureUpdateExisting)
+		assert.NoError(t, err)
+		assert.False(t, created)
+		assert.Empty(t, app.Service().Environment())
+		assert.Equal(t, 1, c.called("UpdateService"))
+	})
+}
@@ -0,0 +1,121 @@
+package arukas
+
+import (
+	"encoding/json"
+	"fmt"
+	"time"
+)
+
+// InstanceFailedStatus represents last-instance-failed-status object.
+// The original JSON is kept in Raw because its shape is not documented.
+type InstanceFailedStatus struct {
+	// ExitCode is exit code of the container, nil if unknown
+	ExitCode *int32
+	Reason   string
+	Message  string
+	// Raw is the original JSON
+	Raw json.RawMessage
+}
+
+// failedStatusKeys are keys of each field accepted in last-instance-failed-status
+var failedStatusKeys = map[string][]string{
+	"ExitCode": {"exit-code", "exit_code", "exitCode", "code"},
+	"Reason":   {"reason", "status"},
+	"Message":  {"message", "error"},
+}
+
+// UnmarshalJSON implements json.Unmarshaler.
+// Both object and string are accepted, string is treated as Reason.
+// Other JSON values are only kept in Raw.
+func (s *InstanceFailedStatus) UnmarshalJSON(data []byte) error {
+	*s = InstanceFailedStatus{Raw: append(json.RawMessage{}, data...)}
+
+	var str string
+	if err := json.Unmarshal(data, &str); err == nil {
+		s.Reason = str
+		return nil
+	}
+
+	var values map[string]interface{}
+	if err := json.Unmarshal(data, &values); err != nil {
+		return nil
+	}
+	for _, k := range failedStatusKeys["ExitCode"] {
+		if v, ok := values[k].(float64); ok {
+			code := int32(v)
+			s.ExitCode = &code
+			break
+		}
+	}
+	for _, k := range failedStatusKeys["Reason"] {
+		if v, ok := values[k].(string); ok {
+			s.Reason = v
+			break
+		}
+	}
+	for _, k := range failedStatusKeys["Message"] {
+		if v, ok := values[k].(string); ok {
+			s.Message = v
+			break
+		}
+	}
+	return nil
+}
+
+// MarshalJSON implements json.Marshaler. Raw is used if present.
+func (s *InstanceFailedStatus) MarshalJSON() ([]byte, error) {
+	if len(s.Raw) > 0 {
+		return s.Raw, nil
+	}
+	values := map[string]interface{}{}
+	if s.ExitCode != nil {
+		values["exit-code"] = *s.ExitCode
+	}
+	if s.Reason != "" {
+		values["reason"] = s.Reason
+	}
+	if s.Message != "" {
+		values["message"] = s.Message
+	}
+	return json.Marshal(values)
+}
+
+// String returns human readable status
+func (s *InstanceFailedStatus) String() string {
+	res := s.Reason
+	if res == "" {
+		res = "unknown"
+	}
+	if s.ExitCode != nil {
+		res += fmt.Sprintf("(exit code %d)", *s.ExitCode)
+	}
+	if s.Message != "" {
+		res += ": " + s.Message
+	}
+	return res
+}
+
+// FailureInfo represents information of last instance failure
+type FailureInfo struct {
+	FailedAt *time.Time
+	Status   *InstanceFailedStatus
+}
+
+// FailureInfo returns information of last instance failure, returns nil if the service never failed
+func (s *Service) FailureInfo() *FailureInfo {
+	if s.Attributes == nil {
+		return nil
+	}
+	if s.Attributes.LastInstanceFailedAt == nil && s.Attributes.LastInstanceFailedStatus == nil {
+		return nil
+	}
+	return &FailureInfo{
+		FailedAt: s.Attributes.LastInstanceFailedAt,
+		Status:   s.Attributes.LastInstanceFailedStatus,
+	}
+}
+
+// FailureInfo returns information of last instance failure, returns nil if the service never failed
+func (s *ServiceData) FailureInfo() *FailureInfo {
+	return s.Data.FailureInfo()
+}
@@ -11,6 +11,7 @@ import (
 	"reflect"
 	"sort"
 	"strings"
+	"sync/atomic"
 	"time"
 )
 
@@ -33,6 +34,17 @@ type httpClient struct {
 	trace      bool
 	traceOut   io.Writer
 	timeout    time.Duration
+	portFormat PortFormat
+	// detectedPortFormat is a PortFormat detected from responses, accessed atomically
+	detectedPortFormat int32
+}
+
+// requestPortFormat returns PortFormat to encode request body
+func (c *httpClient) requestPortFormat() PortFormat {
+	if c.portFormat == PortFormatAuto {
+		return PortFormat(atomic.LoadInt32(&c.detectedPortFormat))
+	}
+	return c.portFormat
 }
 
 func (c *httpClient) get(path string) ([]byte, error) {
@@ -121,6 +133,10 @@ func (c *httpClient) doRequest(method, path string, body interface{}) ([]byte, e
 		if err != nil {
 			return []byte{}, err
 		}
+		marshaled, err = convertPortFormat(marshaled, c.requestPortFormat())
+		if err != nil {
+			return []byte{}, err
+		}
 
 		if c.trace {
 			fmt.Fprintln(c.traceOut, "json: ", string(marshaled)) // nolint
@@ -176,13 +192,28 @@ func (c *httpClient) do(req *http.Request) ([]byte, error) {
 		return []byte{}, err
 	}
 
+	if c.portFormat == PortFormatAuto {
+		if format, ok := DetectPortFormat(body); ok {
+			atomic.StoreInt32(&c.detectedPortFormat, int32(format))
+		}
+	}
+
 	return body, err
 }
 
+// httpNotFoundError is the concrete type of ErrorNotFound returned on HTTP 404
+type httpNotFoundError struct {
+	url string
+}
+
+func (e *httpNotFoundError) Error() string {
+	return fmt.Sprintf("The resource does not found on the server: %s", e.url)
+}
+
 // CheckResponse returns an error (of type *Error) if the response.
 func checkResponse(res *http.Response, body []byte) error {
 	if res.StatusCode == 404 {
-		return ErrorNotFound(fmt.Errorf("The resource does not found on the server: %s", res.Request.URL))
+		return ErrorNotFound(&httpNotFoundError{url: res.Request.URL.String()})
 	} else if res.StatusCode >= 400 {
 		msg := string(body)
 		if msg == "" {
@@ -0,0 +1,425 @@
+package arukas
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/hashicorp/go-multierror"
+)
+
+const defaultLinkSyncInterval = 30 * time.Second
+
+// Fields of ServiceRef
+const (
+	// ServiceRefHost is a host of the port mapping
+	ServiceRefHost = "host"
+	// ServiceRefPort is a service port of the port mapping
+	ServiceRefPort = "port"
+	// ServiceRefAddress is "host:port" of the port mapping
+	ServiceRefAddress = "address"
+	// ServiceRefURL is a connection string built by ConnectionStrings, without password
+	ServiceRefURL = "url"
+)
+
+// serviceRefPattern matches "${service:...}"
+var serviceRefPattern = regexp.MustCompile(`\$\{service:([^}]*)\}`)
+
+// ServiceRef represents a reference to an endpoint of other service.
+//
+// Supported forms are:
+//
+//	${service:<app name or service ID>.port.<container port>.host}
+//	${service:<app name or service ID>.port.<container port>.port}
+//	${service:<app name or service ID>.port.<container port>.address}
+//	${service:<app name or service ID>.url}
+//
+// References are resolved with the first instance of the service.
+type ServiceRef struct {
+	// Raw is the reference itself. e.g. "${service:db.port.5432.host}"
+	Raw string
+	// Service is an app name or a service ID
+	Service string
+	// ContainerPort is 0 for ServiceRefURL
+	ContainerPort int32
+	// Field is one of ServiceRefHost, ServiceRefPort, ServiceRefAddress or ServiceRefURL
+	Field string
+}
+
+// ParseServiceRefs returns references contained in str
+func ParseServiceRefs(str string) ([]*ServiceRef, error) {
+	var refs []*ServiceRef
+	var results error
+	for _, m := range serviceRefPattern.FindAllStringSubmatch(str, -1) {
+		ref, err := parseServiceRef(m[0], m[1])
+		if err != nil {
+			results = multierror.Append(results, err)
+			continue
+		}
+		refs = append(refs, ref)
+	}
+	return refs, results
+}
+
+func parseServiceRef(raw, body string) (*ServiceRef, error) {
+	invalid := fmt.Errorf("invalid service reference %q", raw)
+
+	// app name may contain ".", so the body is parsed from the end
+	if strings.HasSuffix(body, "."+ServiceRefURL) {
+		name := strings.TrimSuffix(body, "."+ServiceRefURL)
+		if name == "" {
+			return nil, invalid
+		}
+		return &ServiceRef{Raw: raw, Service: name, Field: ServiceRefURL}, nil
+	}
+
+	tokens := strings.Split(body, ".")
+	if len(tokens) < 4 || tokens[len(tokens)-3] != "port" {
+		return nil, invalid
+	}
+	field := tokens[len(tokens)-1]
+	switch field {
+	case ServiceRefHost, ServiceRefPort, ServiceRefAddress:
+	default:
+		return nil, invalid
+	}
+	port, err := strconv.ParseUint(tokens[len(tokens)-2], 10, 16)
+	if err != nil || port == 0 {
+		return nil, invalid
+	}
+	name := strings.Join(tokens[:len(tokens)-3], ".")
+	if name == "" {
+		return nil, invalid
+	}
+	return &ServiceRef{Raw: raw, Service: name, ContainerPort: int32(port), Field: field}, nil
+}
+
+// ServiceRefs returns references contained in Command and values of Environment
+func (p *RequestParam) ServiceRefs() ([]*ServiceRef, error) {
+	var refs []*ServiceRef
+	var results error
+
+	add := func(str string) {
+		r, err := ParseServiceRefs(str)
+		if err != nil {
+			results = multierror.Append(results, err)
+		}
+		refs = append(refs, r...)
+	}
+	add(p.Command)
+	for _, env := range p.Environment {
+		if env != nil {
+			add(env.Value)
+		}
+	}
+	return refs, results
+}
+
+// ResolveServiceRefs returns a copy of p whose references are replaced with current values
+// read from PortMappings of referenced services.
+// An error is returned if referenced service isn't found or has no port mapping of the container port.
+func ResolveServiceRefs(c Client, p *RequestParam) (*RequestParam, error) {
+	resolved, _, err := newServiceRefResolver(c).resolve(p)
+	return resolved, err
+}
+
+// serviceRefResolver resolves references with services read once per resolver
+type serviceRefResolver struct {
+	client   Client
+	services map[string]*Service
+}
+
+func newServiceRefResolver(c Client) *serviceRefResolver {
+	return &serviceRefResolver{client: c, services: map[string]*Service{}}
+}
+
+// resolve returns a copy of p with resolved references and resolved values keyed by ServiceRef.Raw
+func (r *serviceRefResolver) resolve(p *RequestParam) (*RequestParam, map[string]string, error) {
+	if p == nil {
+		return nil, nil, errors.New("param is nil")
+	}
+	refs, err := p.ServiceRefs()
+	if err != nil {
+		return nil, nil, err
+	}
+
+	values := map[string]string{}
+	var results error
+	for _, ref := range refs {
+		if _, ok := values[ref.Raw]; ok {
+			continue
+		}
+		v, err := r.value(ref)
+		if err != nil {
+			results = multierror.Append(results, fmt.Errorf("%s: %s", ref.Raw, err))
+			continue
+		}
+		values[ref.Raw] = v
+	}
+	if results != nil {
+		return nil, nil, results
+	}
+
+	replace := func(str string) string {
+		return serviceRefPattern.ReplaceAllStringFunc(str, func(raw string) string {
+			return values[raw]
+		})
+	}
+	resolved := *p
+	resolved.Command = replace(p.Command)
+	resolved.Environment = nil
+	for _, env := range p.Environment {
+		if env == nil {
+			resolved.Environment = append(resolved.Environment, env)
+			continue
+		}
+		resolved.Environment = append(resolved.Environment, &Env{Key: env.Key, Value: replace(env.Value)})
+	}
+	return &resolved, values, nil
+}
+
+func (r *serviceRefResolver) value(ref *ServiceRef) (string, error) {
+	s, err := r.service(ref.Service)
+	if err != nil {
+		return "", err
+	}
+
+	if ref.Field == ServiceRefURL {
+		strs := ConnectionStrings(s)
+		if len(strs) == 0 {
+			return "", fmt.Errorf("service %s has no connection string", s.ID)
+		}
+		return strs[0], nil
+	}
+
+	for _, ep := range ConnectionInfo(s) {
+		if ep.Instance != 0 || ep.ContainerPort != ref.ContainerPort {
+			continue
+		}
+		switch ref.Field {
+		case ServiceRefHost:
+			return ep.Host, nil
+		case ServiceRefPort:
+			return strconv.Itoa(int(ep.Port)), nil
+		default:
+			return ep.Address(), nil
+		}
+	}
+	return "", fmt.Errorf("service %s has no port mapping of container port %d", s.ID, ref.ContainerPort)
+}
+
+func (r *serviceRefResolver) service(nameOrID string) (*Service, error) {
+	if s, ok := r.services[nameOrID]; ok {
+		return s, nil
+	}
+	id, err := ResolveServiceID(r.client, nameOrID)
+	if err != nil {
+		return nil, err
+	}
+	s, err := r.client.ReadService(id)
+	if err != nil {
+		return nil, err
+	}
+	r.services[nameOrID] = s.Data
+	return s.Data, nil
+}
+
+// Linker applies RequestParam which contains references to other services,
+// and re-applies dependents when port mappings of referenced services are changed.
+type Linker struct {
+	client Client
+
+	mu         sync.Mutex
+	dependents map[string]*linkedService
+}
+
+// linkedService represents a dependent service and values applied last time
+type linkedService struct {
+	param  *RequestParam
+	values map[string]string
+}
+
+// NewLinker returns new Linker
+func NewLinker(c Client) *Linker {
+	return &Linker{
+		client:     c,
+		dependents: map[string]*linkedService{},
+	}
+}
+
+// CreateApp resolves references in p, creates the app and registers its service as a dependent
+func (l *Linker) CreateApp(p *RequestParam) (*AppData, error) {
+	resolved, values, err := newServiceRefResolver(l.client).resolve(p)
+	if err != nil {
+		return nil, err
+	}
+	app, err := l.client.CreateApp(resolved)
+	if err != nil {
+		return nil, err
+	}
+	if s := app.Service(); s != nil {
+		l.register(s.ID, p, values)
+	}
+	return app, nil
+}
+
+// UpdateService resolves references in p, updates the service and registers it as a dependent
+func (l *Linker) UpdateService(id string, p *RequestParam) (*ServiceData, error) {
+	resolved, values, err := newServiceRefResolver(l.client).resolve(p)
+	if err != nil {
+		return nil, err
+	}
+	s, err := l.client.UpdateService(id, resolved)
+	if err != nil {
+		return nil, err
+	}
+	l.register(id, p, values)
+	return s, nil
+}
+
+// Unlink stops re-applying the service
+func (l *Linker) Unlink(id string) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	delete(l.dependents, id)
+}
+
+func (l *Linker) register(id string, p *RequestParam, values map[string]string) {
+	param := *p
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.dependents[id] = &linkedService{param: &param, values: values}
+}
+
+// Sync resolves references of all dependents, and patches Command and Environment of dependents
+// whose resolved values are changed. Other fields are left unchanged.
+// It returns IDs of updated services.
+func (l *Linker) Sync(ctx context.Context) ([]string, error) {
+	l.mu.Lock()
+	ids := make([]string, 0, len(l.dependents))
+	for id := range l.dependents {
+		ids = append(ids, id)
+	}
+	l.mu.Unlock()
+	sort.Strings(ids)
+
+	resolver := newServiceRefResolver(l.client)
+	var updated []string
+	var results error
+	for _, id := range ids {
+		if err := ctx.Err(); err != nil {
+			return updated, err
+		}
+
+		l.mu.Lock()
+		dep, ok := l.dependents[id]
+		l.mu.Unlock()
+		if !ok {
+			continue
+		}
+
+		resolved, values, err := resolver.resolve(dep.param)
+		if err != nil {
+			results = multierror.Append(results, fmt.Errorf("%s: %s", id, err))
+			continue
+		}
+		if equalStringMap(dep.values, values) {
+			continue
+		}
+		if err := l.apply(ctx, id, dep.param, resolved); err != nil {
+			results = multierror.Append(results, fmt.Errorf("%s: %s", id, err))
+			continue
+		}
+		l.register(id, dep.param, values)
+		updated = append(updated, id)
+	}
+	return updated, results
+}
+
+// apply patches only fields of the service which contain references,
+// so other changes made since the service was linked are kept.
+// Environment variables without references are taken from the current spec.
+func (l *Linker) apply(ctx context.Context, id string, param, resolved *RequestParam) error {
+	patch := &ServicePatch{}
+	if serviceRefPattern.MatchString(param.Command) {
+		patch.Command = String(resolved.Command)
+	}
+
+	linked := map[string]string{}
+	var keys []string
+	for i, env := range param.Environment {
+		if env != nil && serviceRefPattern.MatchString(env.Value) {
+			linked[env.Key] = resolved.Environment[i].Value
+			keys = append(keys, env.Key)
+		}
+	}
+	if len(linked) > 0 {
+		current, err := l.client.ReadService(id)
+		if err != nil {
+			return err
+		}
+		patch.Environment = []*Env{}
+		for _, env := range current.Data.ToRequestParam().Environment {
+			if env == nil {
+				continue
+			}
+			if v, ok := linked[env.Key]; ok {
+				patch.Environment = append(patch.Environment, &Env{Key: env.Key, Value: v})
+				delete(linked, env.Key)
+				continue
+			}
+			patch.Environment = append(patch.Environment, env)
+		}
+		// variables removed out of band are added again
+		for _, k := range keys {
+			if v, ok := linked[k]; ok {
+				patch.Environment = append(patch.Environment, &Env{Key: k, Value: v})
+			}
+		}
+	}
+
+	if patch.Command == nil && patch.Environment == nil {
+		return nil
+	}
+	_, err := l.client.PatchService(ctx, id, patch)
+	return err
+}
+
+// Run calls Sync with interval until ctx is done. onError is called when Sync returns an error.
+func (l *Linker) Run(ctx context.Context, interval time.Duration, onError func(err error)) error {
+	if interval <= 0 {
+		interval = defaultLinkSyncInterval
+	}
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		if _, err := l.Sync(ctx); err != nil && onError != nil && ctx.Err() == nil {
+			onError(err)
+		}
+
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-ticker.C:
+		}
+	}
+}
+
+func equalStringMap(a, b map[string]string) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for k, v := range a {
+		if w, ok := b[k]; !ok || v != w {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,203 @@
+package arukas
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestParseServiceRefs(t *testing.T) {
+	expects := []struct {
+		scenario string
+		input    string
+		expect   []*ServiceRef
+		err      bool
+	}{
+		{
+			scenario: "No reference",
+			input:    "postgres://localhost:5432",
+		},
+		{
+			scenario: "Port fields",
+			input:    "${service:db.port.5432.host}:${service:db.port.5432.port}",
+			expect: []*ServiceRef{
+				{Raw: "${service:db.port.5432.host}", Service: "db", ContainerPort: 5432, Field: ServiceRefHost},
+				{Raw: "${service:db.port.5432.port}", Service: "db", ContainerPort: 5432, Field: ServiceRefPort},
+			},
+		},
+		{
+			scenario: "Name with dot",
+			input:    "${service:my.db.port.6379.address}",
+			expect: []*ServiceRef{
+				{Raw: "${service:my.db.port.6379.address}", Service: "my.db", ContainerPort: 6379, Field: ServiceRefAddress},
+			},
+		},
+		{
+			scenario: "URL",
+			input:    "${service:db.url}",
+			expect: []*ServiceRef{
+				{Raw: "${service:db.url}", Service: "db", Field: ServiceRefURL},
+			},
+		},
+		{
+			scenario: "Invalid field",
+			input:    "${service:db.port.5432.user}",
+			err:      true,
+		},
+		{
+			scenario: "Invalid port",
+			input:    "${service:db.port.70000.host}",
+			err:      true,
+		},
+		{
+			scenario: "Missing name",
+			input:    "${service:port.5432.host}",
+			err:      true,
+		},
+	}
+
+	for _, expect := range expects {
+		t.Run(expect.scenario, func(t *testing.T) {
+			refs, err := ParseServiceRefs(expect.input)
+			assert.Equal(t, expect.err, err != nil)
+			assert.Equal(t, expect.expect, refs)
+		})
+	}
+}
+
+func newLinkTestClient() *testClient {
+	c := newTestClient()
+	c.addApp(testAppID, testServiceID, "db", &ServiceAttr{
+		Image:  "postgres",
+		Status: StatusRunning,
+		PortMappings: [][]*PortMapping{
+			{{Host: "seaof-1.arukas.io", Protocol: "tcp", ContainerPort: 5432, ServicePort: 31000}},
+		},
+	})
+	c.addApp(testAnotherAppID, testAnotherSvcID, "web", &ServiceAttr{Image: "nginx", Instances: 1})
+	return c
+}
+
+func linkTestParam() *RequestParam {
+	p := testRequestParam("web")
+	p.Environment = []*Env{
+		{Key: "DB_ADDR", Value: "${service:db.port.5432.host}:${service:db.port.5432.port}"},
+		{Key: "DATABASE_URL", Value: "${service:db.url}"},
+	}
+	return p
+}
+
+func TestLinker_KeepChangesOutOfBand(t *testing.T) {
+	ctx := context.Background()
+	c := newLinkTestClient()
+	l := NewLinker(c)
+
+	_, err := l.UpdateService(testAnotherSvcID, linkTestParam())
+	assert.NoError(t, err)
+
+	// dependent is changed without Linker
+	c.updateAttr(testAnotherSvcID, func(attr *ServiceAttr) {
+		attr.Image = "nginx:2"
+		attr.Instances = 3
+		attr.Environment = append(attr.Environment, &Env{Key: "MODE", Value: "prod"})
+	})
+	c.updateAttr(testServiceID, func(attr *ServiceAttr) {
+		attr.PortMappings = [][]*PortMapping{
+			{{Host: "seaof-2.arukas.io", Protocol: "tcp", ContainerPort: 5432, ServicePort: 31005}},
+		}
+	})
+
+	updated, err := l.Sync(ctx)
+	assert.NoError(t, err)
+	assert.Equal(t, []string{testAnotherSvcID}, updated)
+
+	s, _ := c.ReadService(testAnotherSvcID)
+	assert.Equal(t, "nginx:2", s.Image())
+	assert.Equal(t, int32(3), s.Instances())
+	assert.Equal(t, []*Env{
+		{Key: "DB_ADDR", Value: "seaof-2.arukas.io:31005"},
+		{Key: "DATABASE_URL", Value: "postgres://postgres@seaof-2.arukas.io:31005/postgres"},
+		{Key: "MODE", Value: "prod"},
+	}, s.Environment())
+}
+
+func TestResolveServiceRefs(t *testing.T) {
+	t.Run("Resolved", func(t *testing.T) {
+		c := newLinkTestClient()
+		p := linkTestParam()
+
+		resolved, err := ResolveServiceRefs(c, p)
+		assert.NoError(t, err)
+		assert.Equal(t, "seaof-1.arukas.io:31000", resolved.Environment[0].Value)
+		assert.Equal(t, "postgres://postgres@seaof-1.arukas.io:31000/postgres", resolved.Environment[1].Value)
+		assert.Equal(t, 1, c.called("ReadService"))
+
+		// original param is kept
+		assert.Equal(t, "${service:db.url}", p.Environment[1].Value)
+	})
+
+	t.Run("Not running", func(t *testing.T) {
+		c := newLinkTestClient()
+		c.updateAttr(testServiceID, func(attr *ServiceAttr) {
+			attr.PortMappings = nil
+		})
+
+		_, err := ResolveServiceRefs(c, linkTestParam())
+		assert.Error(t, err)
+	})
+
+	t.Run("Unknown service", func(t *testing.T) {
+		c := newLinkTestClient()
+		p := linkTestParam()
+		p.Command = "${service:cache.port.6379.host}"
+
+		_, err := ResolveServiceRefs(c, p)
+		assert.Error(t, err)
+	})
+}
+
+func TestLinker(t *testing.T) {
+	ctx := context.Background()
+	c := newLinkTestClient()
+	l := NewLinker(c)
+
+	_, err := l.UpdateService(testAnotherSvcID, linkTestParam())
+	assert.NoError(t, err)
+	s, _ := c.ReadService(testAnotherSvcID)
+	assert.Equal(t, "seaof-1.arukas.io:31000", s.Environment()[0].Value)
+
+	// not changed
+	updated, err := l.Sync(ctx)
+	assert.NoError(t, err)
+	assert.Empty(t, updated)
+	assert.Equal(t, 1, c.called("UpdateService"))
+
+	// restarted on other host
+	c.updateAttr(testServiceID, func(attr *ServiceAttr) {
+		attr.PortMappings = [][]*PortMapping{
+			{{Host: "seaof-2.arukas.io", Protocol: "tcp", ContainerPort: 5432, ServicePort: 31005}},
+		}
+	})
+	updated, err = l.Sync(ctx)
+	assert.NoError(t, err)
+	assert.Equal(t, []string{testAnotherSvcID}, updated)
+	s, _ = c.ReadService(testAnotherSvcID)
+	assert.Equal(t, "seaof-2.arukas.io:31005", s.Environment()[0].Value)
+	assert.Equal(t, "postgres://postgres@seaof-2.arukas.io:31005/postgres", s.Environment()[1].Value)
+	assert.Equal(t, 1, c.called("UpdateService"))
+	assert.Equal(t, 1, c.called("PatchService"))
+
+	// stopped, previous values are kept
+	c.updateAttr(testServiceID, func(attr *ServiceAttr) {
+		attr.PortMappings = nil
+	})
+	_, err = l.Sync(ctx)
+	assert.Error(t, err)
+	assert.Equal(t, 1, c.called("PatchService"))
+
+	l.Unlink(testAnotherSvcID)
+	updated, err = l.Sync(ctx)
+	assert.NoError(t, err)
+	assert.Empty(t, updated)
+}
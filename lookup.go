@@ -0,0 +1,190 @@
+package arukas
+
+import (
+	"fmt"
+	"net/url"
+	"strings"
+
+	"github.com/google/uuid"
+)
+
+// NotFoundError represents an error that no resource matches lookup condition
+type NotFoundError struct {
+	// Type is a resource type. e.g. "apps", "services"
+	Type string
+	// Field is a name of lookup condition. e.g. "Name", "EndPoint"
+	Field string
+	Value string
+}
+
+// Error implements error interface
+func (e *NotFoundError) Error() string {
+	return fmt.Sprintf("%s with %s %q is not found", e.Type, e.Field, e.Value)
+}
+
+// AmbiguousMatchError represents an error that multiple resources match lookup condition
+type AmbiguousMatchError struct {
+	// Type is a resource type. e.g. "apps", "services"
+	Type string
+	// Field is a name of lookup condition. e.g. "Name", "EndPoint"
+	Field string
+	Value string
+	// IDs are IDs of matched resources
+	IDs []string
+}
+
+// Error implements error interface
+func (e *AmbiguousMatchError) Error() string {
+	return fmt.Sprintf("%s with %s %q is ambiguous: matched [%s]", e.Type, e.Field, e.Value, strings.Join(e.IDs, ", "))
+}
+
+// FindAppByName returns the app which has specified name
+func FindAppByName(c Client, name string) (*App, error) {
+	if err := validateRequired("Name", name); err != nil {
+		return nil, err
+	}
+	list, err := c.ListApps()
+	if err != nil {
+		return nil, err
+	}
+
+	var found []*App
+	for _, app := range list.Data {
+		if app.Attributes != nil && app.Name() == name {
+			found = append(found, app)
+		}
+	}
+
+	switch len(found) {
+	case 0:
+		return nil, &NotFoundError{Type: TypeApps, Field: "Name", Value: name}
+	case 1:
+		return found[0], nil
+	default:
+		ids := make([]string, 0, len(found))
+		for _, app := range found {
+			ids = append(ids, app.ID)
+		}
+		return nil, &AmbiguousMatchError{Type: TypeApps, Field: "Name", Value: name, IDs: ids}
+	}
+}
+
+// FindServiceByEndpoint returns the service which has specified endpoint.
+// endpoint accepts both hostname and URL. e.g. "foo.arukascloud.io", "https://foo.arukascloud.io/"
+func FindServiceByEndpoint(c Client, endpoint string) (*Service, error) {
+	if err := validateRequired("EndPoint", endpoint); err != nil {
+		return nil, err
+	}
+	host := normalizeHost(endpoint)
+	return findService(c, "EndPoint", endpoint, func(s *Service) bool {
+		return normalizeHost(s.EndPoint()) == host
+	})
+}
+
+// FindServiceBySubDomain returns the service which has specified subdomain
+func FindServiceBySubDomain(c Client, subDomain string) (*Service, error) {
+	if err := validateRequired("SubDomain", subDomain); err != nil {
+		return nil, err
+	}
+	return findService(c, "SubDomain", subDomain, func(s *Service) bool {
+		return strings.EqualFold(s.SubDomain(), subDomain)
+	})
+}
+
+// FindServiceByCustomDomain returns the service which has specified custom domain
+func FindServiceByCustomDomain(c Client, domain string) (*Service, error) {
+	if err := validateRequired("CustomDomain", domain); err != nil {
+		return nil, err
+	}
+	host := normalizeHost(domain)
+	return findService(c, "CustomDomain", domain, func(s *Service) bool {
+		for _, d := range s.Attributes.CustomDomains {
+			if d != nil && normalizeHost(d.Name) == host {
+				return true
+			}
+		}
+		return false
+	})
+}
+
+// ResolveAppID returns app ID from UUID or app name.
+// If nameOrID is UUID, it is returned as is without calling API.
+func ResolveAppID(c Client, nameOrID string) (string, error) {
+	if isUUID(nameOrID) {
+		return nameOrID, nil
+	}
+	app, err := FindAppByName(c, nameOrID)
+	if err != nil {
+		return "", err
+	}
+	return app.ID, nil
+}
+
+// ResolveServiceID returns service ID from UUID or app name.
+// If nameOrID is UUID, it is returned as is without calling API.
+// If the app has multiple services, *AmbiguousMatchError is returned.
+func ResolveServiceID(c Client, nameOrID string) (string, error) {
+	if isUUID(nameOrID) {
+		return nameOrID, nil
+	}
+	app, err := FindAppByName(c, nameOrID)
+	if err != nil {
+		return "", err
+	}
+
+	ids := app.ServiceIDs()
+	switch len(ids) {
+	case 0:
+		return "", &NotFoundError{Type: TypeServices, Field: "AppName", Value: nameOrID}
+	case 1:
+		return ids[0], nil
+	default:
+		return "", &AmbiguousMatchError{Type: TypeServices, Field: "AppName", Value: nameOrID, IDs: ids}
+	}
+}
+
+func findService(c Client, field, value string, match func(s *Service) bool) (*Service, error) {
+	list, err := c.ListServices()
+	if err != nil {
+		return nil, err
+	}
+
+	var found []*Service
+	for _, s := range list.Data {
+		if s.Attributes != nil && match(s) {
+			found = append(found, s)
+		}
+	}
+
+	switch len(found) {
+	case 0:
+		return nil, &NotFoundError{Type: TypeServices, Field: field, Value: value}
+	case 1:
+		return found[0], nil
+	default:
+		ids := make([]string, 0, len(found))
+		for _, s := range found {
+			ids = append(ids, s.ID)
+		}
+		return nil, &AmbiguousMatchError{Type: TypeServices, Field: field, Value: value, IDs: ids}
+	}
+}
+
+// normalizeHost returns lower-cased hostname from hostname or URL
+func normalizeHost(v string) string {
+	v = strings.TrimSpace(v)
+	if strings.Contains(v, "://") {
+		if u, err := url.Parse(v); err == nil {
+			v = u.Host
+		}
+	}
+	if i := strings.IndexAny(v, "/:"); i >= 0 {
+		v = v[:i]
+	}
+	return strings.ToLower(strings.TrimSuffix(v, "."))
+}
+
+func isUUID(v string) bool {
+	_, err := uuid.Parse(v)
+	return err == nil
+}
@@ -0,0 +1,104 @@
+package arukas
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func newLookupTestClient() *testClient {
+	c := newTestClient()
+	c.addApp(testAppID, testServiceID, "foo", &ServiceAttr{
+		SubDomain:     "foo",
+		EndPoint:      "foo.arukascloud.io",
+		CustomDomains: CustomDomains("www.example.com"),
+	})
+	c.addApp(testAnotherAppID, testAnotherSvcID, "bar", &ServiceAttr{
+		SubDomain: "bar",
+		EndPoint:  "bar.arukascloud.io",
+	})
+	return c
+}
+
+func TestFindAppByName(t *testing.T) {
+	c := newLookupTestClient()
+
+	app, err := FindAppByName(c, "bar")
+	assert.NoError(t, err)
+	assert.Equal(t, testAnotherAppID, app.ID)
+
+	_, err = FindAppByName(c, "baz")
+	assert.IsType(t, &NotFoundError{}, err)
+
+	c.addApp("6E3A5AB1-7D2B-4D4B-9C4E-2C5E8F1D1A03", "01BEF829-72E4-48F9-81DA-E3B41A1EDAC7", "bar", nil)
+	_, err = FindAppByName(c, "bar")
+	assert.IsType(t, &AmbiguousMatchError{}, err)
+	assert.Len(t, err.(*AmbiguousMatchError).IDs, 2)
+}
+
+func TestFindService(t *testing.T) {
+	c := newLookupTestClient()
+
+	expects := []struct {
+		scenario string
+		find     func() (*Service, error)
+		expectID string
+	}{
+		{
+			scenario: "EndPoint with hostname",
+			find:     func() (*Service, error) { return FindServiceByEndpoint(c, "foo.arukascloud.io") },
+			expectID: testServiceID,
+		},
+		{
+			scenario: "EndPoint with URL",
+			find:     func() (*Service, error) { return FindServiceByEndpoint(c, "https://BAR.arukascloud.io:443/path") },
+			expectID: testAnotherSvcID,
+		},
+		{
+			scenario: "SubDomain",
+			find:     func() (*Service, error) { return FindServiceBySubDomain(c, "bar") },
+			expectID: testAnotherSvcID,
+		},
+		{
+			scenario: "CustomDomain",
+			find:     func() (*Service, error) { return FindServiceByCustomDomain(c, "www.example.com.") },
+			expectID: testServiceID,
+		},
+		{
+			scenario: "Not found",
+			find:     func() (*Service, error) { return FindServiceByCustomDomain(c, "example.com") },
+		},
+	}
+
+	for _, expect := range expects {
+		t.Run(expect.scenario, func(t *testing.T) {
+			s, err := expect.find()
+			if expect.expectID == "" {
+				assert.IsType(t, &NotFoundError{}, err)
+				return
+			}
+			assert.NoError(t, err)
+			assert.Equal(t, expect.expectID, s.ID)
+		})
+	}
+}
+
+func TestResolveID(t *testing.T) {
+	c := newLookupTestClient()
+
+	id, err := ResolveAppID(c, "foo")
+	assert.NoError(t, err)
+	assert.Equal(t, testAppID, id)
+
+	id, err = ResolveAppID(c, testAnotherAppID)
+	assert.NoError(t, err)
+	assert.Equal(t, testAnotherAppID, id)
+	assert.Equal(t, 1, c.called("ListApps"))
+
+	id, err = ResolveServiceID(c, "bar")
+	assert.NoError(t, err)
+	assert.Equal(t, testAnotherSvcID, id)
+
+	_, err = ResolveServiceID(c, "baz")
+	assert.IsType(t, &NotFoundError{}, err)
+}
@@ -1,6 +1,9 @@
 package arukas
 
-import "fmt"
+import (
+	"fmt"
+	"strings"
+)
 
 const (
 	// RegionJPTokyo represents the "jp-tokyo" region
@@ -29,3 +32,170 @@ var (
 func PlanID(region, plan string) string {
 	return fmt.Sprintf("%s/%s", region, plan)
 }
+
+// Plan represents a service plan and its resource limits
+type Plan struct {
+	// ID is a plan ID. e.g. "jp-tokyo/free"
+	ID     string `json:"id"`
+	Region string `json:"region"`
+	Name   string `json:"name"`
+	// CPUs is a number of vCPU per instance
+	CPUs float32 `json:"cpus"`
+	// MemoryMB is a size of memory per instance in MB
+	MemoryMB int32 `json:"memory"`
+	// MaxInstances is the max number of instances per service
+	MaxInstances int32 `json:"max-instances"`
+	// MonthlyPrice is a price per instance in JPY
+	MonthlyPrice float64 `json:"monthly-price"`
+	// FreeTier is limits of free tier. nil if the plan is not free
+	FreeTier *FreeTierLimits `json:"free-tier,omitempty"`
+}
+
+// FreeTierLimits represents limits of free tier plan
+type FreeTierLimits struct {
+	// MaxServices is the max number of services per account
+	MaxServices int32 `json:"max-services"`
+}
+
+// IsFree returns true if the plan is free tier
+func (p *Plan) IsFree() bool {
+	return p.FreeTier != nil
+}
+
+// copy returns a deep copy of the plan
+func (p *Plan) copy() *Plan {
+	res := *p
+	if p.FreeTier != nil {
+		freeTier := *p.FreeTier
+		res.FreeTier = &freeTier
+	}
+	return &res
+}
+
+// DefaultPlans is a static catalog of plans, used when the API doesn't provide plans
+var DefaultPlans = []*Plan{
+	{
+		ID:           PlanID(RegionJPTokyo, PlanFree),
+		Region:       RegionJPTokyo,
+		Name:         PlanFree,
+		CPUs:         0.1,
+		MemoryMB:     128,
+		MaxInstances: 1,
+		MonthlyPrice: 0,
+		FreeTier: &FreeTierLimits{
+			MaxServices: 1,
+		},
+	},
+	{
+		ID:           PlanID(RegionJPTokyo, PlanHobby),
+		Region:       RegionJPTokyo,
+		Name:         PlanHobby,
+		CPUs:         0.5,
+		MemoryMB:     512,
+		MaxInstances: 10,
+		MonthlyPrice: 500,
+	},
+	{
+		ID:           PlanID(RegionJPTokyo, PlanStandard1),
+		Region:       RegionJPTokyo,
+		Name:         PlanStandard1,
+		CPUs:         1,
+		MemoryMB:     1024,
+		MaxInstances: 10,
+		MonthlyPrice: 1000,
+	},
+	{
+		ID:           PlanID(RegionJPTokyo, PlanStandard2),
+		Region:       RegionJPTokyo,
+		Name:         PlanStandard2,
+		CPUs:         2,
+		MemoryMB:     2048,
+		MaxInstances: 10,
+		MonthlyPrice: 2000,
+	},
+}
+
+// defaultPlans returns a copy of DefaultPlans
+func defaultPlans() []*Plan {
+	res := make([]*Plan, 0, len(DefaultPlans))
+	for _, p := range DefaultPlans {
+		res = append(res, p.copy())
+	}
+	return res
+}
+
+// FindPlan returns plan from DefaultPlans by region and plan name.
+// If region is empty, RegionJPTokyo is used. Returns nil if not found.
+func FindPlan(region, plan string) *Plan {
+	if region == "" {
+		region = RegionJPTokyo
+	}
+	return FindPlanByID(PlanID(region, plan))
+}
+
+// FindPlanByID returns plan from DefaultPlans by plan ID. Returns nil if not found.
+func FindPlanByID(id string) *Plan {
+	for _, p := range DefaultPlans {
+		if p.ID == id {
+			return p
+		}
+	}
+	return nil
+}
+
+// ServicePlanListData represents service plans data
+type ServicePlanListData struct {
+	Data []*ServicePlan `json:"data"`
+}
+
+// ServicePlan represents service_plan object
+type ServicePlan struct {
+	ID         string           `json:"id"`
+	Type       string           `json:"type,omitempty"`
+	Attributes *ServicePlanAttr `json:"attributes,omitempty"`
+}
+
+// ServicePlanAttr represents service_plan.attributes object
+type ServicePlanAttr struct {
+	Name         string  `json:"name,omitempty"`
+	Region       string  `json:"region,omitempty"`
+	CPUs         float32 `json:"cpus,omitempty"`
+	Memory       int32   `json:"memory,omitempty"`
+	MaxInstances int32   `json:"max-instances,omitempty"`
+	Price        float64 `json:"price,omitempty"`
+}
+
+// Plan returns *Plan built from ServicePlan.
+// Attributes not provided by the API are filled with DefaultPlans.
+func (s *ServicePlan) Plan() *Plan {
+	plan := &Plan{ID: s.ID}
+	if p := FindPlanByID(s.ID); p != nil {
+		plan = p.copy()
+	} else if parts := strings.SplitN(s.ID, "/", 2); len(parts) == 2 {
+		plan.Region, plan.Name = parts[0], parts[1]
+	}
+
+	attr := s.Attributes
+	if attr == nil {
+		return plan
+	}
+	if attr.Name != "" {
+		plan.Name = attr.Name
+	}
+	if attr.Region != "" {
+		plan.Region = attr.Region
+	}
+	if attr.CPUs > 0 {
+		plan.CPUs = attr.CPUs
+	}
+	if attr.Memory > 0 {
+		plan.MemoryMB = attr.Memory
+	}
+	if attr.MaxInstances > 0 {
+		plan.MaxInstances = attr.MaxInstances
+	}
+	if attr.Price > 0 {
+		plan.MonthlyPrice = attr.Price
+	}
+	return plan
+}
@@ -0,0 +1,479 @@
+package arukas
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+)
+
+// Severity represents how a policy violation is treated
+type Severity int
+
+const (
+	// SeverityDeny rejects the mutation
+	SeverityDeny Severity = iota
+	// SeverityWarn reports the violation but allows the mutation
+	SeverityWarn
+)
+
+// String returns name of the severity
+func (s Severity) String() string {
+	switch s {
+	case SeverityDeny:
+		return "deny"
+	case SeverityWarn:
+		return "warn"
+	default:
+		return "unknown"
+	}
+}
+
+// MarshalJSON implements json.Marshaler
+func (s Severity) MarshalJSON() ([]byte, error) {
+	return json.Marshal(s.String())
+}
+
+// UnmarshalJSON implements json.Unmarshaler. Empty string is treated as SeverityDeny.
+func (s *Severity) UnmarshalJSON(data []byte) error {
+	var str string
+	if err := json.Unmarshal(data, &str); err != nil {
+		return err
+	}
+	switch str {
+	case "", "deny":
+		*s = SeverityDeny
+	case "warn":
+		*s = SeverityWarn
+	default:
+		return fmt.Errorf("invalid severity: %q", str)
+	}
+	return nil
+}
+
+// Violation represents a violation of a policy rule
+type Violation struct {
+	Rule     string   `json:"rule"`
+	Field    string   `json:"field,omitempty"`
+	Message  string   `json:"message"`
+	Severity Severity `json:"severity"`
+}
+
+// String returns human readable violation
+func (v *Violation) String() string {
+	if v.Field == "" {
+		return fmt.Sprintf("[%s] %s: %s", v.Severity, v.Rule, v.Message)
+	}
+	return fmt.Sprintf("[%s] %s: %q %s", v.Severity, v.Rule, v.Field, v.Message)
+}
+
+// Policy evaluates RequestParam before mutations
+type Policy interface {
+	Evaluate(p *RequestParam) []*Violation
+}
+
+// PolicySet is a Policy which evaluates all policies
+type PolicySet []Policy
+
+// Evaluate implements Policy interface
+func (s PolicySet) Evaluate(p *RequestParam) []*Violation {
+	var res []*Violation
+	for _, policy := range s {
+		res = append(res, policy.Evaluate(p)...)
+	}
+	return res
+}
+
+// PolicyReport represents violations of a RequestParam
+type PolicyReport struct {
+	Violations []*Violation `json:"violations"`
+}
+
+// EvaluatePolicy evaluates p with policy and returns *PolicyReport
+func EvaluatePolicy(policy Policy, p *RequestParam) *PolicyReport {
+	return &PolicyReport{Violations: policy.Evaluate(p)}
+}
+
+// Warnings returns violations of SeverityWarn
+func (r *PolicyReport) Warnings() []*Violation {
+	return r.filter(SeverityWarn)
+}
+
+// Denies returns violations of SeverityDeny
+func (r *PolicyReport) Denies() []*Violation {
+	return r.filter(SeverityDeny)
+}
+
+// Allowed returns true if the report has no deny
+func (r *PolicyReport) Allowed() bool {
+	return len(r.Denies()) == 0
+}
+
+func (r *PolicyReport) filter(severity Severity) []*Violation {
+	var res []*Violation
+	for _, v := range r.Violations {
+		if v.Severity == severity {
+			res = append(res, v)
+		}
+	}
+	return res
+}
+
+// PolicyDeniedError represents an error that a mutation is denied by policy
+type PolicyDeniedError struct {
+	// Method is name of Client method. e.g. "CreateApp"
+	Method string
+	Report *PolicyReport
+}
+
+// Error implements error interface
+func (e *PolicyDeniedError) Error() string {
+	var denies []string
+	for _, v := range e.Report.Denies() {
+		denies = append(denies, v.String())
+	}
+	return fmt.Sprintf("%s is denied by policy: [%s]", e.Method, strings.Join(denies, ", "))
+}
+
+// Built-in rule types, used as "type" in policy file
+const (
+	RuleAllowedImageNamespaces = "allowed-image-namespaces"
+	RuleNoLatestTag            = "no-latest-tag"
+	RuleMaxInstances           = "max-instances"
+	RuleRequiredEnv            = "required-env"
+	RuleNoPrivilegedPorts      = "no-privileged-ports"
+)
+
+// AllowedImageNamespaces allows only images in specified Docker Hub namespaces.
+// Official images such as "nginx" are in "library" namespace.
+// Images of other registries are always violations.
+type AllowedImageNamespaces struct {
+	Namespaces []string
+	Severity   Severity
+}
+
+// Evaluate implements Policy interface
+func (r *AllowedImageNamespaces) Evaluate(p *RequestParam) []*Violation {
+	if p.Image == "" {
+		return nil
+	}
+	domain, namespace := imageNamespace(p.Image)
+	if domain != "" {
+		return []*Violation{{
+			Rule:     RuleAllowedImageNamespaces,
+			Field:    "Image",
+			Message:  fmt.Sprintf("registry %q is not allowed", domain),
+			Severity: r.Severity,
+		}}
+	}
+	for _, ns := range r.Namespaces {
+		if ns == namespace {
+			return nil
+		}
+	}
+	return []*Violation{{
+		Rule:     RuleAllowedImageNamespaces,
+		Field:    "Image",
+		Message:  fmt.Sprintf("namespace %q is not allowed", namespace),
+		Severity: r.Severity,
+	}}
+}
+
+// NoLatestTag rejects images with "latest" tag or without tag.
+// Images pinned by digest are allowed.
+type NoLatestTag struct {
+	Severity Severity
+}
+
+// Evaluate implements Policy interface
+func (r *NoLatestTag) Evaluate(p *RequestParam) []*Violation {
+	if p.Image == "" || strings.Contains(p.Image, "@") {
+		return nil
+	}
+	tag := "latest"
+	if i := strings.LastIndex(p.Image, ":"); i > strings.LastIndex(p.Image, "/") {
+		tag = p.Image[i+1:]
+	}
+	if tag != "latest" {
+		return nil
+	}
+	return []*Violation{{
+		Rule:     RuleNoLatestTag,
+		Field:    "Image",
+		Message:  "must have a tag other than latest",
+		Severity: r.Severity,
+	}}
+}
+
+// MaxInstances limits number of instances per plan name such as "free" or "standard-1"
+type MaxInstances struct {
+	Plans    map[string]int32
+	Severity Severity
+}
+
+// Evaluate implements Policy interface
+func (r *MaxInstances) Evaluate(p *RequestParam) []*Violation {
+	max, ok := r.Plans[p.Plan]
+	if !ok || p.Instances <= max {
+		return nil
+	}
+	return []*Violation{{
+		Rule:     RuleMaxInstances,
+		Field:    "Instances",
+		Message:  fmt.Sprintf("must be less than or equal to %d for plan %q", max, p.Plan),
+		Severity: r.Severity,
+	}}
+}
+
+// RequiredEnv requires environment variables which have specified keys
+type RequiredEnv struct {
+	Keys     []string
+	Severity Severity
+}
+
+// Evaluate implements Policy interface
+func (r *RequiredEnv) Evaluate(p *RequestParam) []*Violation {
+	keys := map[string]bool{}
+	for _, env := range p.Environment {
+		if env != nil {
+			keys[env.Key] = true
+		}
+	}
+	var res []*Violation
+	for _, key := range r.Keys {
+		if !keys[key] {
+			res = append(res, &Violation{
+				Rule:     RuleRequiredEnv,
+				Field:    "Environment",
+				Message:  fmt.Sprintf("must have key %q", key),
+				Severity: r.Severity,
+			})
+		}
+	}
+	return res
+}
+
+// NoPrivilegedPorts rejects ports less than 1024
+type NoPrivilegedPorts struct {
+	Severity Severity
+}
+
+// Evaluate implements Policy interface
+func (r *NoPrivilegedPorts) Evaluate(p *RequestParam) []*Violation {
+	var res []*Violation
+	for i, port := range p.Ports {
+		if port != nil && port.Number < 1024 {
+			res = append(res, &Violation{
+				Rule:     RuleNoPrivilegedPorts,
+				Field:    fieldPath("Ports", i, "Number"),
+				Message:  fmt.Sprintf("%s is a privileged port", port),
+				Severity: r.Severity,
+			})
+		}
+	}
+	return res
+}
+
+// imageNamespace returns registry domain and Docker Hub namespace of the image.
+// domain is empty for Docker Hub images.
+func imageNamespace(image string) (domain, namespace string) {
+	name := image
+	if i := strings.Index(name, "@"); i >= 0 {
+		name = name[:i]
+	}
+	components := strings.Split(name, "/")
+	first := components[0]
+	if len(components) > 1 && (strings.ContainsAny(first, ".:") || first == "localhost") {
+		switch first {
+		case "docker.io", "index.docker.io", "registry-1.docker.io":
+			components = components[1:]
+		default:
+			return first, ""
+		}
+	}
+	if len(components) == 1 {
+		return "", "library"
+	}
+	return "", components[0]
+}
+
+// policyRuleConfig represents a rule in policy file
+type policyRuleConfig struct {
+	Type       string           `json:"type"`
+	Severity   Severity         `json:"severity"`
+	Namespaces []string         `json:"namespaces"`
+	Plans      map[string]int32 `json:"plans"`
+	Keys       []string         `json:"keys"`
+}
+
+// ParsePolicy reads rules from JSON such as:
+//
+//	{
+//	  "rules": [
+//	    {"type": "allowed-image-namespaces", "namespaces": ["library", "example"]},
+//	    {"type": "no-latest-tag", "severity": "warn"},
+//	    {"type": "max-instances", "plans": {"free": 1, "standard-1": 5}},
+//	    {"type": "required-env", "keys": ["APP_ENV"]},
+//	    {"type": "no-privileged-ports"}
+//	  ]
+//	}
+//
+// Severity is "deny"(default) or "warn".
+func ParsePolicy(r io.Reader) (PolicySet, error) {
+	var config struct {
+		Rules []*policyRuleConfig `json:"rules"`
+	}
+	dec := json.NewDecoder(r)
+	dec.DisallowUnknownFields()
+	if err := dec.Decode(&config); err != nil {
+		return nil, err
+	}
+
+	var set PolicySet
+	for i, rule := range config.Rules {
+		policy, err := rule.policy()
+		if err != nil {
+			return nil, fmt.Errorf("rules[%d]: %s", i, err)
+		}
+		set = append(set, policy)
+	}
+	return set, nil
+}
+
+// LoadPolicyFile reads rules from the file, see ParsePolicy for the format
+func LoadPolicyFile(path string) (PolicySet, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close() // nolint
+	return ParsePolicy(f)
+}
+
+func (c *policyRuleConfig) policy() (Policy, error) {
+	switch c.Type {
+	case RuleAllowedImageNamespaces:
+		if len(c.Namespaces) == 0 {
+			return nil, errors.New("namespaces is required")
+		}
+		return &AllowedImageNamespaces{Namespaces: c.Namespaces, Severity: c.Severity}, nil
+	case RuleNoLatestTag:
+		return &NoLatestTag{Severity: c.Severity}, nil
+	case RuleMaxInstances:
+		if len(c.Plans) == 0 {
+			return nil, errors.New("plans is required")
+		}
+		return &MaxInstances{Plans: c.Plans, Severity: c.Severity}, nil
+	case RuleRequiredEnv:
+		if len(c.Keys) == 0 {
+			return nil, errors.New("keys is required")
+		}
+		return &RequiredEnv{Keys: c.Keys, Severity: c.Severity}, nil
+	case RuleNoPrivilegedPorts:
+		return &NoPrivilegedPorts{Severity: c.Severity}, nil
+	default:
+		return nil, fmt.Errorf("unknown rule type: %q", c.Type)
+	}
+}
+
+// PolicyClient evaluates Policy before CreateApp/CreateAppWithSpec/UpdateService/PatchService.
+// Mutations which have denies are rejected with *PolicyDeniedError.
+type PolicyClient struct {
+	Client
+	policy Policy
+	// onWarning is called when a mutation is allowed with warnings
+	onWarning func(method string, report *PolicyReport)
+}
+
+// NewPolicyClient returns a new PolicyClient wrapping c.
+// onWarning is called when a mutation is allowed with warnings, it may be nil.
+func NewPolicyClient(c Client, policy Policy, onWarning func(method string, report *PolicyReport)) *PolicyClient {
+	return &PolicyClient{Client: c, policy: policy, onWarning: onWarning}
+}
+
+// check evaluates params and returns *PolicyDeniedError if denied.
+// Fields of violations are prefixed with prefixes[i] for params[i].
+func (c *PolicyClient) check(method string, params []*RequestParam, prefixes []string) error {
+	report := &PolicyReport{}
+	for i, p := range params {
+		for _, v := range c.policy.Evaluate(p) {
+			if v.Field != "" {
+				v.Field = prefixes[i] + v.Field
+			}
+			report.Violations = append(report.Violations, v)
+		}
+	}
+	if !report.Allowed() {
+		return &PolicyDeniedError{Method: method, Report: report}
+	}
+	if len(report.Violations) > 0 && c.onWarning != nil {
+		c.onWarning(method, report)
+	}
+	return nil
+}
+
+// CreateApp evaluates policy and creates the app if allowed
+func (c *PolicyClient) CreateApp(param *RequestParam) (*AppData, error) {
+	if param != nil {
+		if err := c.check("CreateApp", []*RequestParam{param}, []string{""}); err != nil {
+			return nil, err
+		}
+	}
+	return c.Client.CreateApp(param)
+}
+
+// CreateAppWithSpec evaluates policy for each service and creates the app if allowed
+func (c *PolicyClient) CreateAppWithSpec(spec *AppSpec) (*AppData, error) {
+	if spec != nil {
+		var params []*RequestParam
+		var prefixes []string
+		for i := range spec.Services {
+			params = append(params, spec.Services[i].requestParam(spec.Name))
+			prefixes = append(prefixes, fieldPath("Services", i, "")+".")
+		}
+		if err := c.check("CreateAppWithSpec", params, prefixes); err != nil {
+			return nil, err
+		}
+	}
+	return c.Client.CreateAppWithSpec(spec)
+}
+
+// UpdateService evaluates policy and updates the service if allowed.
+// If param.Plan is empty, the current plan of the service is used for evaluation because it is kept by the update.
+func (c *PolicyClient) UpdateService(id string, param *RequestParam) (*ServiceData, error) {
+	if param != nil {
+		p := param
+		if param.Plan == "" {
+			current, err := c.Client.ReadService(id)
+			if err != nil {
+				return nil, err
+			}
+			currentParam := current.Data.ToRequestParam()
+			merged := *param
+			merged.Region, merged.Plan = currentParam.Region, currentParam.Plan
+			p = &merged
+		}
+		if err := c.check("UpdateService", []*RequestParam{p}, []string{""}); err != nil {
+			return nil, err
+		}
+	}
+	return c.Client.UpdateService(id, param)
+}
+
+// PatchService evaluates policy with the current spec merged with patch, and patches the service if allowed
+func (c *PolicyClient) PatchService(ctx context.Context, id string, patch *ServicePatch) (*ServiceData, error) {
+	if patch != nil {
+		current, err := c.Client.ReadService(id)
+		if err != nil {
+			return nil, err
+		}
+		param := patch.applyTo(current.Data.ToRequestParam())
+		if err := c.check("PatchService", []*RequestParam{param}, []string{""}); err != nil {
+			return nil, err
+		}
+	}
+	return c.Client.PatchService(ctx, id, patch)
+}
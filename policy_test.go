@@ -0,0 +1,172 @@
+package arukas
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+const testPolicyJSON = `{
+  "rules": [
+    {"type": "allowed-image-namespaces", "namespaces": ["library", "example"]},
+    {"type": "no-latest-tag"},
+    {"type": "max-instances", "plans": {"free": 1}},
+    {"type": "required-env", "severity": "warn", "keys": ["APP_ENV"]},
+    {"type": "no-privileged-ports", "severity": "warn"}
+  ]
+}`
+
+func policyTestParam() *RequestParam {
+	return &RequestParam{
+		Name:        "foo",
+		Image:       "example/app:1.0",
+		Instances:   1,
+		Ports:       Ports{{Number: 8080, Protocol: "tcp"}},
+		Environment: []*Env{{Key: "APP_ENV", Value: "production"}},
+		Plan:        PlanFree,
+	}
+}
+
+func TestPolicy_Rules(t *testing.T) {
+	policy, err := ParsePolicy(strings.NewReader(testPolicyJSON))
+	assert.NoError(t, err)
+	assert.Len(t, policy, 5)
+
+	expects := []struct {
+		scenario string
+		modify   func(p *RequestParam)
+		denies   []string
+		warnings []string
+	}{
+		{
+			scenario: "Allowed",
+			modify:   func(p *RequestParam) {},
+		},
+		{
+			scenario: "Official image",
+			modify:   func(p *RequestParam) { p.Image = "docker.io/nginx:1.15" },
+		},
+		{
+			scenario: "Other namespace",
+			modify:   func(p *RequestParam) { p.Image = "someone/app:1.0" },
+			denies:   []string{RuleAllowedImageNamespaces},
+		},
+		{
+			scenario: "Other registry",
+			modify:   func(p *RequestParam) { p.Image = "gcr.io/example/app:1.0" },
+			denies:   []string{RuleAllowedImageNamespaces},
+		},
+		{
+			scenario: "Latest tag",
+			modify:   func(p *RequestParam) { p.Image = "example/app" },
+			denies:   []string{RuleNoLatestTag},
+		},
+		{
+			scenario: "Digest",
+			modify:   func(p *RequestParam) { p.Image = "example/app@sha256:0123456789abcdef0123456789abcdef" },
+		},
+		{
+			scenario: "Too many instances",
+			modify:   func(p *RequestParam) { p.Instances = 2 },
+			denies:   []string{RuleMaxInstances},
+		},
+		{
+			scenario: "Warnings",
+			modify: func(p *RequestParam) {
+				p.Environment = nil
+				p.Ports = Ports{{Number: 80, Protocol: "tcp"}}
+			},
+			warnings: []string{RuleRequiredEnv, RuleNoPrivilegedPorts},
+		},
+	}
+
+	rules := func(violations []*Violation) []string {
+		var res []string
+		for _, v := range violations {
+			res = append(res, v.Rule)
+		}
+		return res
+	}
+
+	for _, expect := range expects {
+		t.Run(expect.scenario, func(t *testing.T) {
+			p := policyTestParam()
+			expect.modify(p)
+			report := EvaluatePolicy(policy, p)
+			assert.Equal(t, expect.denies, rules(report.Denies()))
+			assert.Equal(t, expect.warnings, rules(report.Warnings()))
+			assert.Equal(t, len(expect.denies) == 0, report.Allowed())
+		})
+	}
+}
+
+func TestParsePolicy_Invalid(t *testing.T) {
+	for _, input := range []string{
+		`{"rules": [{"type": "foo"}]}`,
+		`{"rules": [{"type": "required-env"}]}`,
+		`{"rules": [{"type": "no-latest-tag", "severity": "info"}]}`,
+		`{"rules": [{"type": "no-latest-tag", "foo": "bar"}]}`,
+	} {
+		_, err := ParsePolicy(strings.NewReader(input))
+		assert.Error(t, err, input)
+	}
+}
+
+func TestPolicyClient(t *testing.T) {
+	ctx := context.Background()
+	policy, err := ParsePolicy(strings.NewReader(testPolicyJSON))
+	assert.NoError(t, err)
+
+	base := newTestClient()
+	base.addApp(testAppID, testServiceID, "foo", &ServiceAttr{
+		Image:       "example/app:1.0",
+		Instances:   1,
+		Ports:       Ports{{Number: 8080, Protocol: "tcp"}},
+		Environment: []*Env{{Key: "APP_ENV", Value: "production"}},
+	})
+	var warnings []*PolicyReport
+	c := NewPolicyClient(base, policy, func(method string, report *PolicyReport) {
+		warnings = append(warnings, report)
+	})
+
+	p := policyTestParam()
+	p.Image = "nginx"
+	_, err = c.CreateApp(p)
+	assert.IsType(t, &PolicyDeniedError{}, err)
+	assert.Equal(t, 0, base.called("CreateApp"))
+
+	_, err = c.CreateAppWithSpec(&AppSpec{
+		Name:     "bar",
+		Services: []ServiceSpec{{Image: "example/app:1.0", Instances: 3, Ports: Ports{{Number: 8080, Protocol: "tcp"}}, Plan: PlanFree}},
+	})
+	assert.IsType(t, &PolicyDeniedError{}, err)
+	fields := []string{}
+	for _, v := range err.(*PolicyDeniedError).Report.Violations {
+		fields = append(fields, v.Field)
+	}
+	assert.Equal(t, []string{"Services[0].Instances", "Services[0].Environment"}, fields)
+
+	// merged with current spec
+	_, err = c.PatchService(ctx, testServiceID, &ServicePatch{Image: String("example/app:latest")})
+	assert.IsType(t, &PolicyDeniedError{}, err)
+	_, err = c.PatchService(ctx, testServiceID, &ServicePatch{Image: String("example/app:1.1")})
+	assert.NoError(t, err)
+	assert.Empty(t, warnings)
+
+	p = policyTestParam()
+	p.Environment = nil
+	_, err = c.UpdateService(testServiceID, p)
+	assert.NoError(t, err)
+	assert.Len(t, warnings, 1)
+	assert.Equal(t, RuleRequiredEnv, warnings[0].Warnings()[0].Rule)
+
+	// current plan is used if Plan is empty
+	p = policyTestParam()
+	p.Plan = ""
+	p.Instances = 2
+	_, err = c.UpdateService(testServiceID, p)
+	assert.IsType(t, &PolicyDeniedError{}, err)
+	assert.Equal(t, 1, base.called("UpdateService"))
+}
@@ -0,0 +1,138 @@
+package arukas
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+)
+
+// PortFormat represents wire format of ports in request body
+type PortFormat int
+
+const (
+	// PortFormatString encodes ports as list of strings. e.g. ["80/tcp"]
+	PortFormatString PortFormat = iota
+	// PortFormatObject encodes ports as list of objects. e.g. [{"protocol": "tcp", "number": 80}]
+	PortFormatObject
+	// PortFormatAuto uses the format detected from server responses.
+	// PortFormatString is used until the format is detected.
+	PortFormatAuto
+)
+
+// String returns name of the format
+func (f PortFormat) String() string {
+	switch f {
+	case PortFormatString:
+		return "string"
+	case PortFormatObject:
+		return "object"
+	case PortFormatAuto:
+		return "auto"
+	default:
+		return fmt.Sprintf("PortFormat(%d)", int(f))
+	}
+}
+
+// portObject represents port in object format
+type portObject struct {
+	Protocol string `json:"protocol"`
+	Number   int32  `json:"number"`
+}
+
+// MarshalPorts encodes ports in the format. PortFormatAuto is treated as PortFormatString.
+func (f PortFormat) MarshalPorts(ports Ports) ([]byte, error) {
+	if f != PortFormatObject {
+		return json.Marshal(ports)
+	}
+
+	objects := make([]*portObject, 0, len(ports))
+	for _, p := range ports {
+		objects = append(objects, &portObject{Protocol: p.Protocol, Number: p.Number})
+	}
+	return json.Marshal(objects)
+}
+
+// DetectPortFormat detects format of ports from JSON such as API response.
+// ok is false if data doesn't contain non-empty ports.
+func DetectPortFormat(data []byte) (format PortFormat, ok bool) {
+	var v interface{}
+	if err := json.Unmarshal(data, &v); err != nil {
+		return PortFormatString, false
+	}
+
+	walkPorts(v, func(ports []interface{}) bool {
+		if len(ports) == 0 {
+			return true
+		}
+		switch ports[0].(type) {
+		case string:
+			format, ok = PortFormatString, true
+		case map[string]interface{}:
+			format, ok = PortFormatObject, true
+		default:
+			return true
+		}
+		return false
+	})
+	return format, ok
+}
+
+// convertPortFormat rewrites all "ports" in JSON body to the format
+func convertPortFormat(body []byte, format PortFormat) ([]byte, error) {
+	if format != PortFormatObject {
+		return body, nil
+	}
+
+	dec := json.NewDecoder(bytes.NewReader(body))
+	dec.UseNumber()
+	var v interface{}
+	if err := dec.Decode(&v); err != nil {
+		return nil, err
+	}
+
+	var convErr error
+	walkPorts(v, func(ports []interface{}) bool {
+		for i, p := range ports {
+			str, isString := p.(string)
+			if !isString {
+				continue
+			}
+			port, err := ParsePort(str)
+			if err != nil {
+				convErr = err
+				return false
+			}
+			ports[i] = &portObject{Protocol: port.Protocol, Number: port.Number}
+		}
+		return true
+	})
+	if convErr != nil {
+		return nil, convErr
+	}
+	return json.Marshal(v)
+}
+
+// walkPorts calls fn with each "ports" list in v. Walking stops when fn returns false.
+func walkPorts(v interface{}, fn func(ports []interface{}) bool) bool {
+	switch t := v.(type) {
+	case map[string]interface{}:
+		for k, child := range t {
+			if ports, ok := child.([]interface{}); ok && k == "ports" {
+				if !fn(ports) {
+					return false
+				}
+				continue
+			}
+			if !walkPorts(child, fn) {
+				return false
+			}
+		}
+	case []interface{}:
+		for _, child := range t {
+			if !walkPorts(child, fn) {
+				return false
+			}
+		}
+	}
+	return true
+}
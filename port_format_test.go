@@ -0,0 +1,131 @@
+package arukas
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+var testPorts = Ports{
+	{Protocol: "tcp", Number: 80},
+	{Protocol: "udp", Number: 34197},
+}
+
+func TestPortFormat_MarshalPorts(t *testing.T) {
+	expects := []struct {
+		format PortFormat
+		json   string
+	}{
+		{format: PortFormatString, json: `["80/tcp", "34197/udp"]`},
+		{format: PortFormatAuto, json: `["80/tcp", "34197/udp"]`},
+		{format: PortFormatObject, json: `[{"protocol": "tcp", "number": 80}, {"protocol": "udp", "number": 34197}]`},
+	}
+
+	for _, expect := range expects {
+		t.Run(expect.format.String(), func(t *testing.T) {
+			data, err := expect.format.MarshalPorts(testPorts)
+			assert.NoError(t, err)
+			assert.JSONEq(t, expect.json, string(data))
+
+			// both formats must be decoded to same ports
+			var decoded Ports
+			assert.NoError(t, json.Unmarshal(data, &decoded))
+			assert.Equal(t, testPorts, decoded)
+		})
+	}
+}
+
+func TestDetectPortFormat(t *testing.T) {
+	expects := []struct {
+		scenario string
+		response string
+		format   PortFormat
+		ok       bool
+	}{
+		{
+			scenario: "Old format",
+			response: `{"data": {"attributes": {"ports": [{"protocol": "tcp", "number": 80}]}}}`,
+			format:   PortFormatObject,
+			ok:       true,
+		},
+		{
+			scenario: "New format in included",
+			response: `{"data": {"attributes": {}}, "included": [{"attributes": {"ports": []}}, {"attributes": {"ports": ["80/tcp"]}}]}`,
+			format:   PortFormatString,
+			ok:       true,
+		},
+		{
+			scenario: "No ports",
+			response: `{"data": []}`,
+		},
+		{
+			scenario: "Invalid JSON",
+			response: `{"data": `,
+		},
+	}
+
+	for _, expect := range expects {
+		t.Run(expect.scenario, func(t *testing.T) {
+			format, ok := DetectPortFormat([]byte(expect.response))
+			assert.Equal(t, expect.ok, ok)
+			if expect.ok {
+				assert.Equal(t, expect.format, format)
+			}
+		})
+	}
+}
+
+func TestHTTPClient_PortFormat(t *testing.T) {
+	var requestBody []byte
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method == http.MethodGet {
+			w.Write([]byte(`{"data": {"attributes": {"ports": [{"protocol": "tcp", "number": 80}]}}}`)) // nolint
+			return
+		}
+		requestBody, _ = ioutil.ReadAll(r.Body)
+		w.Write([]byte(`{}`)) // nolint
+	}))
+	defer server.Close()
+
+	newHTTPClient := func(format PortFormat) *httpClient {
+		u, err := url.Parse(server.URL)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return &httpClient{apiBaseURL: u, traceOut: ioutil.Discard, timeout: defaultTimeout, portFormat: format}
+	}
+	body := validCreateAppParam.ToAppData()
+
+	t.Run("String", func(t *testing.T) {
+		c := newHTTPClient(PortFormatString)
+		_, err := c.post("/apps", body)
+		assert.NoError(t, err)
+		assert.Contains(t, string(requestBody), `"ports":["80/tcp"]`)
+	})
+
+	t.Run("Object", func(t *testing.T) {
+		c := newHTTPClient(PortFormatObject)
+		_, err := c.post("/apps", body)
+		assert.NoError(t, err)
+		assert.Contains(t, string(requestBody), `"ports":[{"protocol":"tcp","number":80}]`)
+	})
+
+	t.Run("Auto", func(t *testing.T) {
+		c := newHTTPClient(PortFormatAuto)
+		_, err := c.post("/apps", body)
+		assert.NoError(t, err)
+		assert.Contains(t, string(requestBody), `"ports":["80/tcp"]`)
+
+		_, err = c.get("/services")
+		assert.NoError(t, err)
+
+		_, err = c.post("/apps", body)
+		assert.NoError(t, err)
+		assert.Contains(t, string(requestBody), `"ports":[{"protocol":"tcp","number":80}]`)
+	})
+}
@@ -0,0 +1,351 @@
+package arukas
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/hashicorp/go-multierror"
+)
+
+const (
+	defaultProbeTimeout   = 5 * time.Second
+	defaultProbeInterval  = 2 * time.Second
+	defaultProbeDeadline  = 2 * time.Minute
+	defaultProbeHTTPPath  = "/"
+	defaultEndpointScheme = "https"
+	defaultEndpointPort   = 443
+	// maxProbeBodySize is max size of HTTP response body read to match ExpectedBody
+	maxProbeBodySize = 1024 * 1024
+)
+
+// ProbeType represents how targets are probed
+type ProbeType int
+
+const (
+	// ProbeTCP dials targets over TCP
+	ProbeTCP ProbeType = iota
+	// ProbeHTTP sends HTTP GET to targets and checks status/body
+	ProbeHTTP
+	// ProbeUDP sends a datagram to targets
+	ProbeUDP
+)
+
+// String returns name of the probe type
+func (t ProbeType) String() string {
+	switch t {
+	case ProbeTCP:
+		return "tcp"
+	case ProbeHTTP:
+		return "http"
+	case ProbeUDP:
+		return "udp"
+	default:
+		return "unknown"
+	}
+}
+
+// Probe target sources
+const (
+	ProbeSourceEndpoint     = "endpoint"
+	ProbeSourceCustomDomain = "custom-domain"
+	ProbeSourcePortMapping  = "port-mapping"
+)
+
+// ProbeOptions represents options of WaitForReachable
+type ProbeOptions struct {
+	Type ProbeType
+
+	// Timeout is timeout of each attempt. default: 5 seconds
+	Timeout time.Duration
+	// Interval is interval between attempts to same target. default: 2 seconds
+	Interval time.Duration
+	// Retries is max number of retries of each target, 0 means retrying until Deadline
+	Retries int
+	// Deadline is time limit of whole probe. default: 2 minutes
+	Deadline time.Duration
+
+	// HTTPPath is path of HTTP GET. default: "/"
+	HTTPPath string
+	// ExpectedStatus is expected HTTP status code. default: any 2xx
+	ExpectedStatus int
+	// ExpectedBody is substring which HTTP response body must contain
+	ExpectedBody string
+
+	// UDPPayload is datagram sent to targets
+	UDPPayload []byte
+	// UDPExpectResponse requires a response datagram, otherwise a successful send is treated as reachable
+	UDPExpectResponse bool
+
+	// SkipEndpoint excludes endpoint and custom domains from targets
+	SkipEndpoint bool
+	// SkipPortMappings excludes port mappings from targets
+	SkipPortMappings bool
+}
+
+func (o *ProbeOptions) setDefaults() {
+	if o.Timeout <= 0 {
+		o.Timeout = defaultProbeTimeout
+	}
+	if o.Interval <= 0 {
+		o.Interval = defaultProbeInterval
+	}
+	if o.Deadline <= 0 {
+		o.Deadline = defaultProbeDeadline
+	}
+	if o.HTTPPath == "" {
+		o.HTTPPath = defaultProbeHTTPPath
+	}
+	if !strings.HasPrefix(o.HTTPPath, "/") {
+		o.HTTPPath = "/" + o.HTTPPath
+	}
+}
+
+// ProbeTarget represents an address to probe
+type ProbeTarget struct {
+	// Source is one of ProbeSourceEndpoint, ProbeSourceCustomDomain or ProbeSourcePortMapping
+	Source string
+	// Address is "host:port"
+	Address string
+	// URL is used for ProbeHTTP
+	URL string
+}
+
+// ProbeResult represents result of probing a target
+type ProbeResult struct {
+	Target    *ProbeTarget
+	Reachable bool
+	Attempts  int
+	// Latency is duration of the last attempt
+	Latency time.Duration
+	// Err is an error of the last attempt
+	Err error
+}
+
+// ProbeReport represents results of all targets
+type ProbeReport struct {
+	Results []*ProbeResult
+}
+
+// Reachable returns true if all targets are reachable
+func (r *ProbeReport) Reachable() bool {
+	return len(r.Unreachable()) == 0
+}
+
+// Unreachable returns results of unreachable targets
+func (r *ProbeReport) Unreachable() []*ProbeResult {
+	var res []*ProbeResult
+	for _, result := range r.Results {
+		if !result.Reachable {
+			res = append(res, result)
+		}
+	}
+	return res
+}
+
+// ProbeTargets returns targets of the service resolved from endpoint, custom domains and port mappings.
+// Endpoint and custom domains are probed with HTTPS(port 443), they are excluded for ProbeUDP.
+// Port mappings are filtered by protocol of the probe type.
+func ProbeTargets(s *Service, opts *ProbeOptions) []*ProbeTarget {
+	o := ProbeOptions{}
+	if opts != nil {
+		o = *opts
+	}
+	o.setDefaults()
+
+	if s == nil || s.Attributes == nil {
+		return nil
+	}
+
+	var targets []*ProbeTarget
+	if !o.SkipEndpoint && o.Type != ProbeUDP {
+		if s.EndPoint() != "" {
+			targets = append(targets, newEndpointTarget(ProbeSourceEndpoint, s.EndPoint(), &o))
+		}
+		for _, d := range s.Attributes.CustomDomains {
+			if d != nil && d.Name != "" {
+				targets = append(targets, newEndpointTarget(ProbeSourceCustomDomain, d.Name, &o))
+			}
+		}
+	}
+
+	if !o.SkipPortMappings {
+		protocol := "tcp"
+		if o.Type == ProbeUDP {
+			protocol = "udp"
+		}
+		for _, mappings := range s.PortMappings() {
+			for _, m := range mappings {
+				if m == nil || m.Host == "" || m.ServicePort == 0 {
+					continue
+				}
+				if m.Protocol != "" && strings.ToLower(m.Protocol) != protocol {
+					continue
+				}
+				address := net.JoinHostPort(m.Host, strconv.Itoa(int(m.ServicePort)))
+				targets = append(targets, &ProbeTarget{
+					Source:  ProbeSourcePortMapping,
+					Address: address,
+					URL:     "http://" + address + o.HTTPPath,
+				})
+			}
+		}
+	}
+	return targets
+}
+
+func newEndpointTarget(source, host string, o *ProbeOptions) *ProbeTarget {
+	host = normalizeHost(host)
+	return &ProbeTarget{
+		Source:  source,
+		Address: net.JoinHostPort(host, strconv.Itoa(defaultEndpointPort)),
+		URL:     defaultEndpointScheme + "://" + host + o.HTTPPath,
+	}
+}
+
+// WaitForReachable probes endpoint, custom domains and port mappings of the service
+// until all targets are reachable or retries/deadline are exhausted.
+// Targets are probed in parallel. Returned error contains errors of unreachable targets.
+func WaitForReachable(ctx context.Context, s *Service, opts ProbeOptions) (*ProbeReport, error) {
+	opts.setDefaults()
+
+	targets := ProbeTargets(s, &opts)
+	if len(targets) == 0 {
+		return &ProbeReport{}, errors.New("service has no target to probe")
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, opts.Deadline)
+	defer cancel()
+
+	p := &prober{opts: opts}
+	report := &ProbeReport{Results: make([]*ProbeResult, len(targets))}
+
+	wg := sync.WaitGroup{}
+	for i, target := range targets {
+		wg.Add(1)
+		go func(i int, target *ProbeTarget) {
+			defer wg.Done()
+			report.Results[i] = p.probeWithRetry(ctx, target)
+		}(i, target)
+	}
+	wg.Wait()
+
+	var errs error
+	for _, r := range report.Unreachable() {
+		errs = multierror.Append(errs, fmt.Errorf("%s %s: %s", r.Target.Source, r.Target.Address, r.Err))
+	}
+	return report, errs
+}
+
+type prober struct {
+	opts ProbeOptions
+}
+
+func (p *prober) probeWithRetry(ctx context.Context, target *ProbeTarget) *ProbeResult {
+	result := &ProbeResult{Target: target}
+	for {
+		result.Attempts++
+		start := time.Now()
+		result.Err = p.probe(ctx, target)
+		result.Latency = time.Since(start)
+		if result.Err == nil {
+			result.Reachable = true
+			return result
+		}
+		if p.opts.Retries > 0 && result.Attempts > p.opts.Retries {
+			return result
+		}
+
+		select {
+		case <-ctx.Done():
+			return result
+		case <-time.After(p.opts.Interval):
+		}
+	}
+}
+
+func (p *prober) probe(ctx context.Context, target *ProbeTarget) error {
+	ctx, cancel := context.WithTimeout(ctx, p.opts.Timeout)
+	defer cancel()
+
+	switch p.opts.Type {
+	case ProbeTCP:
+		return p.probeTCP(ctx, target)
+	case ProbeHTTP:
+		return p.probeHTTP(ctx, target)
+	case ProbeUDP:
+		return p.probeUDP(ctx, target)
+	default:
+		return fmt.Errorf("unknown probe type: %d", p.opts.Type)
+	}
+}
+
+func (p *prober) probeTCP(ctx context.Context, target *ProbeTarget) error {
+	conn, err := (&net.Dialer{}).DialContext(ctx, "tcp", target.Address)
+	if err != nil {
+		return err
+	}
+	return conn.Close()
+}
+
+func (p *prober) probeHTTP(ctx context.Context, target *ProbeTarget) error {
+	req, err := http.NewRequest("GET", target.URL, nil)
+	if err != nil {
+		return err
+	}
+	res, err := http.DefaultClient.Do(req.WithContext(ctx))
+	if err != nil {
+		return err
+	}
+	defer res.Body.Close() // nolint
+
+	if p.opts.ExpectedStatus > 0 {
+		if res.StatusCode != p.opts.ExpectedStatus {
+			return fmt.Errorf("unexpected status: %d", res.StatusCode)
+		}
+	} else if res.StatusCode < 200 || res.StatusCode > 299 {
+		return fmt.Errorf("unexpected status: %d", res.StatusCode)
+	}
+
+	if p.opts.ExpectedBody != "" {
+		body, err := ioutil.ReadAll(io.LimitReader(res.Body, maxProbeBodySize))
+		if err != nil {
+			return err
+		}
+		if !strings.Contains(string(body), p.opts.ExpectedBody) {
+			return fmt.Errorf("response body doesn't contain %q", p.opts.ExpectedBody)
+		}
+	}
+	return nil
+}
+
+func (p *prober) probeUDP(ctx context.Context, target *ProbeTarget) error {
+	conn, err := (&net.Dialer{}).DialContext(ctx, "udp", target.Address)
+	if err != nil {
+		return err
+	}
+	defer conn.Close() // nolint
+
+	if deadline, ok := ctx.Deadline(); ok {
+		if err := conn.SetDeadline(deadline); err != nil {
+			return err
+		}
+	}
+	if _, err := conn.Write(p.opts.UDPPayload); err != nil {
+		return err
+	}
+	if !p.opts.UDPExpectResponse {
+		return nil
+	}
+	buf := make([]byte, 64*1024)
+	_, err = conn.Read(buf)
+	return err
+}
@@ -0,0 +1,199 @@
+package arukas
+
+import (
+	"context"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func probeTestService(protocol string, addrs ...string) *Service {
+	var mappings []*PortMapping
+	for _, addr := range addrs {
+		host, port, _ := net.SplitHostPort(addr)
+		n, _ := strconv.Atoi(port)
+		mappings = append(mappings, &PortMapping{Host: host, Protocol: protocol, ServicePort: int32(n)})
+	}
+	return &Service{
+		ID: testServiceID,
+		Attributes: &ServiceAttr{
+			PortMappings: [][]*PortMapping{mappings},
+		},
+	}
+}
+
+// unusedAddress returns an address which refuses TCP connection
+func unusedAddress(t *testing.T) string {
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	addr := l.Addr().String()
+	l.Close() // nolint
+	return addr
+}
+
+func TestProbeTargets(t *testing.T) {
+	s := &Service{
+		Attributes: &ServiceAttr{
+			EndPoint:      "foo.arukascloud.io",
+			CustomDomains: CustomDomains("www.example.com"),
+			PortMappings: [][]*PortMapping{
+				{
+					{Host: "seaof-1.arukas.io", Protocol: "tcp", ServicePort: 31000},
+					{Host: "seaof-1.arukas.io", Protocol: "udp", ServicePort: 31001},
+				},
+				{
+					{Host: "seaof-2.arukas.io", Protocol: "tcp", ServicePort: 31002},
+				},
+			},
+		},
+	}
+
+	expects := []struct {
+		scenario string
+		opts     *ProbeOptions
+		expect   []*ProbeTarget
+	}{
+		{
+			scenario: "HTTP",
+			opts:     &ProbeOptions{Type: ProbeHTTP, HTTPPath: "health"},
+			expect: []*ProbeTarget{
+				{Source: ProbeSourceEndpoint, Address: "foo.arukascloud.io:443", URL: "https://foo.arukascloud.io/health"},
+				{Source: ProbeSourceCustomDomain, Address: "www.example.com:443", URL: "https://www.example.com/health"},
+				{Source: ProbeSourcePortMapping, Address: "seaof-1.arukas.io:31000", URL: "http://seaof-1.arukas.io:31000/health"},
+				{Source: ProbeSourcePortMapping, Address: "seaof-2.arukas.io:31002", URL: "http://seaof-2.arukas.io:31002/health"},
+			},
+		},
+		{
+			scenario: "UDP",
+			opts:     &ProbeOptions{Type: ProbeUDP},
+			expect: []*ProbeTarget{
+				{Source: ProbeSourcePortMapping, Address: "seaof-1.arukas.io:31001", URL: "http://seaof-1.arukas.io:31001/"},
+			},
+		},
+		{
+			scenario: "Skip port mappings",
+			opts:     &ProbeOptions{SkipPortMappings: true},
+			expect: []*ProbeTarget{
+				{Source: ProbeSourceEndpoint, Address: "foo.arukascloud.io:443", URL: "https://foo.arukascloud.io/"},
+				{Source: ProbeSourceCustomDomain, Address: "www.example.com:443", URL: "https://www.example.com/"},
+			},
+		},
+	}
+
+	for _, expect := range expects {
+		t.Run(expect.scenario, func(t *testing.T) {
+			assert.Equal(t, expect.expect, ProbeTargets(s, expect.opts))
+		})
+	}
+}
+
+func TestWaitForReachable(t *testing.T) {
+	ctx := context.Background()
+
+	t.Run("TCP", func(t *testing.T) {
+		l, err := net.Listen("tcp", "127.0.0.1:0")
+		if err != nil {
+			t.Fatal(err)
+		}
+		defer l.Close() // nolint
+		unused := unusedAddress(t)
+
+		s := probeTestService("tcp", l.Addr().String(), unused)
+		report, err := WaitForReachable(ctx, s, ProbeOptions{Retries: 2, Interval: time.Millisecond})
+		assert.Error(t, err)
+		assert.False(t, report.Reachable())
+
+		assert.Len(t, report.Results, 2)
+		assert.True(t, report.Results[0].Reachable)
+		assert.Equal(t, 1, report.Results[0].Attempts)
+		assert.False(t, report.Results[1].Reachable)
+		assert.Equal(t, 3, report.Results[1].Attempts)
+		assert.Error(t, report.Results[1].Err)
+		assert.Equal(t, unused, report.Unreachable()[0].Target.Address)
+	})
+
+	t.Run("HTTP", func(t *testing.T) {
+		requests := 0
+		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			requests++
+			if r.URL.Path != "/health" || requests < 3 {
+				w.WriteHeader(http.StatusServiceUnavailable)
+				return
+			}
+			w.Write([]byte("status: ok")) // nolint
+		}))
+		defer server.Close()
+
+		s := probeTestService("tcp", server.Listener.Addr().String())
+		report, err := WaitForReachable(ctx, s, ProbeOptions{
+			Type:         ProbeHTTP,
+			HTTPPath:     "/health",
+			ExpectedBody: "ok",
+			Interval:     time.Millisecond,
+		})
+		assert.NoError(t, err)
+		assert.True(t, report.Reachable())
+		assert.Equal(t, 3, report.Results[0].Attempts)
+
+		// unexpected body
+		report, err = WaitForReachable(ctx, s, ProbeOptions{
+			Type:         ProbeHTTP,
+			HTTPPath:     "/health",
+			ExpectedBody: "foo",
+			Retries:      1,
+			Interval:     time.Millisecond,
+		})
+		assert.Error(t, err)
+		assert.False(t, report.Reachable())
+	})
+
+	t.Run("UDP", func(t *testing.T) {
+		conn, err := net.ListenPacket("udp", "127.0.0.1:0")
+		if err != nil {
+			t.Fatal(err)
+		}
+		defer conn.Close() // nolint
+		go func() {
+			buf := make([]byte, 1024)
+			for {
+				n, addr, err := conn.ReadFrom(buf)
+				if err != nil {
+					return
+				}
+				conn.WriteTo(buf[:n], addr) // nolint
+			}
+		}()
+
+		s := probeTestService("udp", conn.LocalAddr().String())
+		report, err := WaitForReachable(ctx, s, ProbeOptions{
+			Type:              ProbeUDP,
+			UDPPayload:        []byte("ping"),
+			UDPExpectResponse: true,
+		})
+		assert.NoError(t, err)
+		assert.True(t, report.Reachable())
+	})
+
+	t.Run("Deadline", func(t *testing.T) {
+		s := probeTestService("tcp", unusedAddress(t))
+		report, err := WaitForReachable(ctx, s, ProbeOptions{
+			Interval: 10 * time.Millisecond,
+			Deadline: 100 * time.Millisecond,
+		})
+		assert.Error(t, err)
+		assert.False(t, report.Reachable())
+		assert.True(t, report.Results[0].Attempts > 1)
+	})
+
+	t.Run("No target", func(t *testing.T) {
+		_, err := WaitForReachable(ctx, &Service{Attributes: &ServiceAttr{}}, ProbeOptions{})
+		assert.Error(t, err)
+	})
+}
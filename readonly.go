@@ -0,0 +1,105 @@
+package arukas
+
+import (
+	"context"
+	"fmt"
+)
+
+// ForbiddenError represents an error that the operation is rejected by ReadOnly or Scoped wrapper
+type ForbiddenError struct {
+	// Method is name of Client method. e.g. "DeleteApp"
+	Method string
+	// ID is ID of target app or service, or app name for CreateApp
+	ID     string
+	Reason string
+}
+
+// Error implements error interface
+func (e *ForbiddenError) Error() string {
+	if e.ID == "" {
+		return fmt.Sprintf("%s is forbidden: %s", e.Method, e.Reason)
+	}
+	return fmt.Sprintf("%s %s is forbidden: %s", e.Method, e.ID, e.Reason)
+}
+
+const readOnlyReason = "client is read-only"
+
+// readOnlyClient rejects all mutations.
+// Client is not embedded so that a new method of Client must be classified here explicitly.
+type readOnlyClient struct {
+	client Client
+}
+
+// ReadOnly returns Client which rejects all mutations with *ForbiddenError.
+// Reads and WaitForState are delegated to c.
+func ReadOnly(c Client) Client {
+	return &readOnlyClient{client: c}
+}
+
+func (c *readOnlyClient) ListApps() (*AppListData, error) {
+	return c.client.ListApps()
+}
+
+func (c *readOnlyClient) ReadApp(id string) (*AppData, error) {
+	return c.client.ReadApp(id)
+}
+
+func (c *readOnlyClient) CreateApp(param *RequestParam) (*AppData, error) {
+	name := ""
+	if param != nil {
+		name = param.Name
+	}
+	return nil, &ForbiddenError{Method: "CreateApp", ID: name, Reason: readOnlyReason}
+}
+
+func (c *readOnlyClient) CreateAppWithSpec(spec *AppSpec) (*AppData, error) {
+	name := ""
+	if spec != nil {
+		name = spec.Name
+	}
+	return nil, &ForbiddenError{Method: "CreateAppWithSpec", ID: name, Reason: readOnlyReason}
+}
+
+func (c *readOnlyClient) UpdateApp(ctx context.Context, id string, patch AppPatch) (*AppData, error) {
+	return nil, &ForbiddenError{Method: "UpdateApp", ID: id, Reason: readOnlyReason}
+}
+
+func (c *readOnlyClient) DeleteApp(id string) error {
+	return &ForbiddenError{Method: "DeleteApp", ID: id, Reason: readOnlyReason}
+}
+
+func (c *readOnlyClient) ListServices() (*ServiceListData, error) {
+	return c.client.ListServices()
+}
+
+func (c *readOnlyClient) ReadService(id string) (*ServiceData, error) {
+	return c.client.ReadService(id)
+}
+
+func (c *readOnlyClient) UpdateService(id string, param *RequestParam) (*ServiceData, error) {
+	return nil, &ForbiddenError{Method: "UpdateService", ID: id, Reason: readOnlyReason}
+}
+
+func (c *readOnlyClient) PatchService(ctx context.Context, id string, patch *ServicePatch) (*ServiceData, error) {
+	return nil, &ForbiddenError{Method: "PatchService", ID: id, Reason: readOnlyReason}
+}
+
+func (c *readOnlyClient) PowerOn(id string) error {
+	return &ForbiddenError{Method: "PowerOn", ID: id, Reason: readOnlyReason}
+}
+
+func (c *readOnlyClient) PowerOff(id string) error {
+	return &ForbiddenError{Method: "PowerOff", ID: id, Reason: readOnlyReason}
+}
+
+func (c *readOnlyClient) WaitForState(ctx context.Context, serviceID string, status string) error {
+	return c.client.WaitForState(ctx, serviceID, status)
+}
+
+func (c *readOnlyClient) ListPlans(ctx context.Context) ([]*Plan, error) {
+	return c.client.ListPlans(ctx)
+}
+
+func (c *readOnlyClient) Version() string {
+	return c.client.Version()
+}
@@ -0,0 +1,58 @@
+package arukas
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestReadOnly(t *testing.T) {
+	ctx := context.Background()
+	base := newTestClient()
+	base.addApp(testAppID, testServiceID, "foo", &ServiceAttr{Image: "nginx", Instances: 1})
+	c := ReadOnly(base)
+
+	apps, err := c.ListApps()
+	assert.NoError(t, err)
+	assert.Len(t, apps.Data, 1)
+	_, err = c.ReadService(testServiceID)
+	assert.NoError(t, err)
+	_, err = c.ListPlans(ctx)
+	assert.NoError(t, err)
+	assert.Equal(t, base.Version(), c.Version())
+
+	mutations := map[string]func() error{
+		"CreateApp": func() error {
+			_, err := c.CreateApp(&RequestParam{Name: "bar"})
+			return err
+		},
+		"CreateAppWithSpec": func() error {
+			_, err := c.CreateAppWithSpec(&AppSpec{Name: "bar"})
+			return err
+		},
+		"UpdateApp": func() error {
+			_, err := c.UpdateApp(ctx, testAppID, AppPatch{Name: String("bar")})
+			return err
+		},
+		"DeleteApp": func() error { return c.DeleteApp(testAppID) },
+		"UpdateService": func() error {
+			_, err := c.UpdateService(testServiceID, &RequestParam{})
+			return err
+		},
+		"PatchService": func() error {
+			_, err := c.PatchService(ctx, testServiceID, &ServicePatch{})
+			return err
+		},
+		"PowerOn":  func() error { return c.PowerOn(testServiceID) },
+		"PowerOff": func() error { return c.PowerOff(testServiceID) },
+	}
+	for method, f := range mutations {
+		t.Run(method, func(t *testing.T) {
+			err := f()
+			assert.IsType(t, &ForbiddenError{}, err)
+			assert.Equal(t, method, err.(*ForbiddenError).Method)
+			assert.Equal(t, 0, base.called(method))
+		})
+	}
+}
@@ -29,16 +29,23 @@ type ServiceRelationship struct {
 	ServicePlan *RelationshipData `json:"service-plan,omitempty"`
 }
 
-// NewAppRelationship creates new AppRelationship with default values
-func NewAppRelationship() *AppRelationship {
+// NewAppRelationship creates new AppRelationship linked to services.
+// If linkIDs is empty, single service with default LinkID is linked.
+func NewAppRelationship(linkIDs ...int32) *AppRelationship {
+	if len(linkIDs) == 0 {
+		linkIDs = []int32{LinkID}
+	}
+
+	services := make([]*Relationship, 0, len(linkIDs))
+	for _, id := range linkIDs {
+		services = append(services, &Relationship{
+			LinkID: id,
+			Type:   TypeServices,
+		})
+	}
 	return &AppRelationship{
 		Services: &RelationshipDataList{
-			Data: []*Relationship{
-				{
-					LinkID: LinkID,
-					Type:   TypeServices,
-				},
-			},
+			Data: services,
 		},
 	}
 
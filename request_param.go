@@ -4,6 +4,15 @@ import (
 	"github.com/hashicorp/go-multierror"
 )
 
+const (
+	// defaultMaxInstances is the max number of instances when plan is unknown
+	defaultMaxInstances = 10
+	// maxEnvKeyLen is the max length of environment variable key in bytes
+	maxEnvKeyLen = 255
+	// maxEnvValueLen is the max length of environment variable value in bytes
+	maxEnvValueLen = 4096
+)
+
 // RequestParam represents request parameter of Arukas.API
 type RequestParam struct {
 	// Name needs only when create app
@@ -40,16 +49,21 @@ func (p *RequestParam) validateOptionals() error {
 		max   int
 	}
 
+	maxInstances := defaultMaxInstances
+	if plan := FindPlan(p.Region, p.Plan); plan != nil {
+		maxInstances = int(plan.MaxInstances)
+	}
+
 	rangeCheckFields := map[string]rangeCheckField{
 		"Instances": {
 			value: int(p.Instances),
 			min:   1,
-			max:   10,
+			max:   maxInstances,
 		},
 		"Ports": {
 			value: len(p.Ports),
 			min:   1,
-			max:   20,
+			max:   maxPorts,
 		},
 		"Environment": {
 			value: len(p.Environment),
@@ -67,11 +81,57 @@ func (p *RequestParam) validateOptionals() error {
 		}
 	}
 
-	for _, port := range p.Ports {
-		if err := validateInStrValues("Protocol", port.Protocol, ValidProtocols...); err != nil {
+	type portKey struct {
+		protocol string
+		number   int32
+	}
+	ports := map[portKey]bool{}
+	for i, port := range p.Ports {
+		if port == nil {
+			results = multierror.Append(results, requiredError(fieldPath("Ports", i, "")))
+			continue
+		}
+		if err := validateInStrValues(fieldPath("Ports", i, "Protocol"), port.Protocol, ValidProtocols...); err != nil {
 			results = multierror.Append(results, err)
 		}
-		if err := validateRange("Number", int(port.Number), 1, 65535); err != nil {
+		if err := validateRange(fieldPath("Ports", i, "Number"), int(port.Number), 1, 65535); err != nil {
+			results = multierror.Append(results, err)
+		}
+		key := portKey{protocol: port.Protocol, number: port.Number}
+		if ports[key] {
+			results = multierror.Append(results, duplicatedError(fieldPath("Ports", i, "")))
+		}
+		ports[key] = true
+	}
+
+	envKeys := map[string]bool{}
+	for i, env := range p.Environment {
+		if env == nil {
+			results = multierror.Append(results, requiredError(fieldPath("Environment", i, "")))
+			continue
+		}
+		keyLabel := fieldPath("Environment", i, "Key")
+		if err := validateRequired(keyLabel, env.Key); err != nil {
+			results = multierror.Append(results, err)
+		} else if envKeys[env.Key] {
+			results = multierror.Append(results, duplicatedError(keyLabel))
+		}
+		envKeys[env.Key] = true
+
+		if err := valiateStrByteLen(keyLabel, env.Key, 0, maxEnvKeyLen); err != nil {
+			results = multierror.Append(results, err)
+		}
+		valueLabel := fieldPath("Environment", i, "Value")
+		if err := valiateStrByteLen(valueLabel, env.Value, 0, maxEnvValueLen); err != nil {
+			results = multierror.Append(results, err)
+		}
+		if err := validateNoServiceRef(valueLabel, env.Value); err != nil {
+			results = multierror.Append(results, err)
+		}
+	}
+
+	if p.Image != "" {
+		if err := validateImageReference("Image", p.Image); err != nil {
 			results = multierror.Append(results, err)
 		}
 	}
@@ -79,11 +139,25 @@ func (p *RequestParam) validateOptionals() error {
 	if err := valiateStrByteLen("Command", p.Command, 0, 4096); err != nil {
 		results = multierror.Append(results, err)
 	}
-
-	if err := valiateStrByteLen("SubDomain", p.SubDomain, 0, 63); err != nil {
+	if err := validateNoNUL("Command", p.Command); err != nil {
+		results = multierror.Append(results, err)
+	}
+	if err := validateNoServiceRef("Command", p.Command); err != nil {
 		results = multierror.Append(results, err)
 	}
 
+	if p.SubDomain != "" {
+		if err := validateDNSLabel("SubDomain", p.SubDomain); err != nil {
+			results = multierror.Append(results, err)
+		}
+	}
+
+	for i, domain := range p.CustomDomains {
+		if err := validateFQDN(fieldPath("CustomDomains", i, ""), domain); err != nil {
+			results = multierror.Append(results, err)
+		}
+	}
+
 	if p.Region != "" {
 		err := validateInStrValues("Region", p.Region, ValidRegions...)
 		if err != nil {
@@ -1,6 +1,8 @@
 package arukas
 
 import (
+	"sort"
+	"strings"
 	"testing"
 
 	"github.com/stretchr/testify/assert"
@@ -140,3 +142,150 @@ func TestRequestParam_ValidateForUpdate(t *testing.T) {
 	}
 
 }
+
+func TestRequestParam_ValidateOptionals(t *testing.T) {
+
+	newParam := func(f func(p *RequestParam)) *RequestParam {
+		p := &RequestParam{
+			Name:      "foobar",
+			Image:     "foobar:latest",
+			Instances: 1,
+			Ports: Ports{
+				{
+					Protocol: "tcp",
+					Number:   80,
+				},
+			},
+			Plan: PlanFree,
+		}
+		f(p)
+		return p
+	}
+
+	expects := []struct {
+		scenario string
+		fields   []string
+		param    *RequestParam
+	}{
+		{
+			scenario: "Valid values",
+			param: newParam(func(p *RequestParam) {
+				p.Image = "registry.example.com:5000/foo/bar-baz:1.0"
+				p.SubDomain = "foo-bar"
+				p.CustomDomains = []string{"www.example.com"}
+				p.Environment = []*Env{{Key: "FOO", Value: "bar"}, {Key: "BAR", Value: ""}}
+				p.Command = "nginx -g 'daemon off;'"
+			}),
+		},
+		{
+			scenario: "Invalid port at index",
+			fields:   []string{"Ports[1].Number"},
+			param: newParam(func(p *RequestParam) {
+				p.Ports = append(p.Ports, &Port{Protocol: "tcp", Number: -1})
+			}),
+		},
+		{
+			scenario: "Duplicated ports",
+			fields:   []string{"Ports[2]"},
+			param: newParam(func(p *RequestParam) {
+				p.Ports = append(p.Ports, &Port{Protocol: "udp", Number: 80}, &Port{Protocol: "tcp", Number: 80})
+			}),
+		},
+		{
+			scenario: "Empty and duplicated env keys",
+			fields:   []string{"Environment[0].Key", "Environment[2].Key"},
+			param: newParam(func(p *RequestParam) {
+				p.Environment = []*Env{{Key: ""}, {Key: "FOO"}, {Key: "FOO"}}
+			}),
+		},
+		{
+			scenario: "Too long env value",
+			fields:   []string{"Environment[0].Value"},
+			param: newParam(func(p *RequestParam) {
+				p.Environment = []*Env{{Key: "FOO", Value: strings.Repeat("a", maxEnvValueLen+1)}}
+			}),
+		},
+		{
+			scenario: "Invalid subdomain",
+			fields:   []string{"SubDomain"},
+			param: newParam(func(p *RequestParam) {
+				p.SubDomain = "-foo_bar"
+			}),
+		},
+		{
+			scenario: "Invalid custom domains",
+			fields:   []string{"CustomDomains[0]", "CustomDomains[1]", "CustomDomains[2]"},
+			param: newParam(func(p *RequestParam) {
+				p.CustomDomains = []string{"localhost", "foo..example.com", "192.168.0.1"}
+			}),
+		},
+		{
+			scenario: "Instances exceeds plan limit",
+			fields:   []string{"Instances"},
+			param: newParam(func(p *RequestParam) {
+				p.Instances = 2
+			}),
+		},
+		{
+			scenario: "Instances within plan limit",
+			param: newParam(func(p *RequestParam) {
+				p.Plan = PlanHobby
+				p.Instances = 10
+			}),
+		},
+		{
+			scenario: "App name is not restricted",
+			param: newParam(func(p *RequestParam) {
+				p.Name = "my app"
+			}),
+		},
+		{
+			scenario: "Invalid image reference",
+			fields:   []string{"Image"},
+			param: newParam(func(p *RequestParam) {
+				p.Image = "Foo/Bar:latest"
+			}),
+		},
+		{
+			scenario: "Command contains NUL",
+			fields:   []string{"Command"},
+			param: newParam(func(p *RequestParam) {
+				p.Command = "foo\x00bar"
+			}),
+		},
+		{
+			scenario: "Unresolved service references",
+			fields:   []string{"Command", "Environment[0].Value"},
+			param: newParam(func(p *RequestParam) {
+				p.Command = "app --db ${service:db.url}"
+				p.Environment = []*Env{{Key: "DB_HOST", Value: "${service:db.port.5432.host}"}}
+			}),
+		},
+	}
+
+	for _, expect := range expects {
+		t.Run(expect.scenario, func(t *testing.T) {
+			err := expect.param.ValidateForCreate()
+
+			var fields []string
+			for _, e := range ValidationErrors(err) {
+				fields = append(fields, e.Field)
+			}
+			sort.Strings(fields)
+			assert.Equal(t, expect.fields, fields)
+		})
+	}
+}
+
+func TestService_ToRequestParam(t *testing.T) {
+	t.Run("Without attributes", func(t *testing.T) {
+		assert.Equal(t, &RequestParam{}, (&Service{ID: testServiceID}).ToRequestParam())
+	})
+
+	t.Run("Nil custom domain", func(t *testing.T) {
+		s := &Service{Attributes: &ServiceAttr{
+			CustomDomains: []*CustomDomain{nil, {Name: "example.com"}},
+		}}
+		assert.Equal(t, []string{"example.com"}, s.ToRequestParam().CustomDomains)
+	})
+}
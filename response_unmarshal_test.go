@@ -62,3 +62,110 @@ func TestUnmarshalNewPortFormat(t *testing.T) {
 	assertPort(t, service.Ports()[1], 443, "tcp")
 	assertPort(t, service.Ports()[2], 34197, "udp")
 }
+
+func TestUnmarshalInvalidPortFormat(t *testing.T) {
+	expects := []string{
+		`["80/sctp"]`,
+		`["80", "443/tcp/extra"]`,
+		`[{"protocol": "tcp", "number": -1}]`,
+		`[{"protocol": "sctp", "number": 80}]`,
+		`[true]`,
+		`{"80": "tcp"}`,
+	}
+
+	for _, expect := range expects {
+		var ports Ports
+		if err := json.Unmarshal([]byte(expect), &ports); err == nil {
+			t.Errorf("Expected error with %s but got %v", expect, ports)
+		}
+	}
+}
+
+func TestUnmarshalMixedPortFormat(t *testing.T) {
+	var ports Ports
+	if err := json.Unmarshal([]byte(`["80", {"protocol": "udp", "number": 53}]`), &ports); err != nil {
+		t.Fatal("Failed to unmarshal mixed format port:", err)
+	}
+
+	assertPort(t, ports[0], 80, "tcp")
+	assertPort(t, ports[1], 53, "udp")
+}
+
+func TestUnmarshalLastInstanceFailedStatus(t *testing.T) {
+	responseBody := `
+		{
+			"data": {
+				"type": "services",
+				"id": "uuid-1",
+				"attributes": {
+					"image": "my-factorio",
+					"last-instance-failed-at": "2018-03-01T10:00:00.000Z",
+					"last-instance-failed-status": {"exit-code": 137, "reason": "OOMKilled", "message": "out of memory", "extra": 1}
+				}
+			}
+		}
+	`
+
+	service := new(ServiceData)
+	if err := json.Unmarshal([]byte(responseBody), service); err != nil {
+		t.Fatal("Failed to unmarshal last-instance-failed-status:", err)
+	}
+
+	info := service.FailureInfo()
+	if info == nil || info.Status == nil || info.FailedAt == nil {
+		t.Fatalf("Expected failure info but got %#v", info)
+	}
+	if info.Status.ExitCode == nil || *info.Status.ExitCode != 137 {
+		t.Errorf("Expected exit code 137 but got %v", info.Status.ExitCode)
+	}
+	if info.Status.Reason != "OOMKilled" || info.Status.Message != "out of memory" {
+		t.Errorf("Unexpected status: %s", info.Status)
+	}
+
+	var raw map[string]interface{}
+	if err := json.Unmarshal(info.Status.Raw, &raw); err != nil || raw["extra"] != float64(1) {
+		t.Errorf("Expected raw JSON is kept but got %s", info.Status.Raw)
+	}
+}
+
+func TestUnmarshalLastInstanceFailedStatusString(t *testing.T) {
+	responseBody := `{"data": {"attributes": {"last-instance-failed-status": "crashed"}}}`
+
+	service := new(ServiceData)
+	if err := json.Unmarshal([]byte(responseBody), service); err != nil {
+		t.Fatal("Failed to unmarshal last-instance-failed-status:", err)
+	}
+	if status := service.FailureInfo().Status; status.Reason != "crashed" || status.ExitCode != nil {
+		t.Errorf("Unexpected status: %s", status)
+	}
+}
+
+func TestUnmarshalLastInstanceFailedStatusOtherTypes(t *testing.T) {
+	for _, raw := range []string{`137`, `["x"]`, `true`} {
+		responseBody := `{"data": {"attributes": {"last-instance-failed-status": ` + raw + `}}}`
+
+		service := new(ServiceData)
+		if err := json.Unmarshal([]byte(responseBody), service); err != nil {
+			t.Fatalf("Failed to unmarshal last-instance-failed-status %s: %s", raw, err)
+		}
+		status := service.FailureInfo().Status
+		if status.ExitCode != nil || status.Reason != "" || status.Message != "" {
+			t.Errorf("Expected empty fields for %s but got %s", raw, status)
+		}
+		if string(status.Raw) != raw {
+			t.Errorf("Expected raw JSON %s but got %s", raw, status.Raw)
+		}
+	}
+}
+
+func TestUnmarshalNoFailure(t *testing.T) {
+	responseBody := `{"data": {"attributes": {"image": "my-factorio"}}}`
+
+	service := new(ServiceData)
+	if err := json.Unmarshal([]byte(responseBody), service); err != nil {
+		t.Fatal("Failed to unmarshal service:", err)
+	}
+	if info := service.FailureInfo(); info != nil {
+		t.Errorf("Expected nil but got %#v", info)
+	}
+}
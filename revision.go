@@ -0,0 +1,301 @@
+package arukas
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Revision represents a service spec saved before an update
+type Revision struct {
+	// Number starts from 1 for each service, assigned by RevisionStore
+	Number    int           `json:"revision"`
+	ServiceID string        `json:"service-id"`
+	Time      time.Time     `json:"time"`
+	Operation string        `json:"operation"`
+	Spec      *RequestParam `json:"spec"`
+}
+
+// RevisionStore stores revisions of services
+type RevisionStore interface {
+	// AddRevision saves r and assigns r.Number
+	AddRevision(r *Revision) error
+	// Revisions returns revisions of the service in ascending order of Number
+	Revisions(serviceID string) ([]*Revision, error)
+}
+
+// FileRevisionStore stores revisions in a JSON file per service
+type FileRevisionStore struct {
+	mu  sync.Mutex
+	dir string
+}
+
+// DefaultRevisionDir returns the default directory of FileRevisionStore, "$HOME/.arukas/revisions"
+func DefaultRevisionDir() (string, error) {
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(home, ".arukas", "revisions"), nil
+}
+
+// NewFileRevisionStore returns *FileRevisionStore which stores files in dir.
+// If dir is empty, DefaultRevisionDir is used.
+func NewFileRevisionStore(dir string) (*FileRevisionStore, error) {
+	if dir == "" {
+		d, err := DefaultRevisionDir()
+		if err != nil {
+			return nil, err
+		}
+		dir = d
+	}
+	if err := os.MkdirAll(dir, 0700); err != nil {
+		return nil, err
+	}
+	return &FileRevisionStore{dir: dir}, nil
+}
+
+func (s *FileRevisionStore) path(serviceID string) string {
+	return filepath.Join(s.dir, serviceID+".json")
+}
+
+// AddRevision implements RevisionStore interface
+func (s *FileRevisionStore) AddRevision(r *Revision) error {
+	if err := validateServiceID("ServiceID", r.ServiceID); err != nil {
+		return err
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	revisions, err := s.read(r.ServiceID)
+	if err != nil {
+		return err
+	}
+	r.Number = 1
+	if len(revisions) > 0 {
+		r.Number = revisions[len(revisions)-1].Number + 1
+	}
+	revisions = append(revisions, r)
+
+	data, err := json.MarshalIndent(revisions, "", "  ")
+	if err != nil {
+		return err
+	}
+	// write to a temporary file and rename it to avoid a broken file
+	tmp, err := ioutil.TempFile(s.dir, r.ServiceID+".*.tmp")
+	if err != nil {
+		return err
+	}
+	if _, err := tmp.Write(data); err != nil {
+		tmp.Close()           // nolint
+		os.Remove(tmp.Name()) // nolint
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		os.Remove(tmp.Name()) // nolint
+		return err
+	}
+	return os.Rename(tmp.Name(), s.path(r.ServiceID))
+}
+
+// Revisions implements RevisionStore interface
+func (s *FileRevisionStore) Revisions(serviceID string) ([]*Revision, error) {
+	if err := validateServiceID("serviceID", serviceID); err != nil {
+		return nil, err
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.read(serviceID)
+}
+
+// validateServiceID validates id which is used as a file name
+func validateServiceID(label, id string) error {
+	if id == "" {
+		return requiredError(label)
+	}
+	return validateID(label, id)
+}
+
+func (s *FileRevisionStore) read(serviceID string) ([]*Revision, error) {
+	data, err := ioutil.ReadFile(s.path(serviceID))
+	if os.IsNotExist(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	var revisions []*Revision
+	if err := json.Unmarshal(data, &revisions); err != nil {
+		return nil, fmt.Errorf("reading revisions of %q: %s", serviceID, err)
+	}
+	return revisions, nil
+}
+
+// RevisionHistory represents a revision and changes made by the next update
+type RevisionHistory struct {
+	*Revision
+	// Changes are "Field: before -> after" made from the revision to the next revision,
+	// or to the current spec for the latest revision. Values of secret environment variables are redacted.
+	Changes []string
+}
+
+// RevisionClient saves the previous spec of the service to RevisionStore when UpdateService/PatchService succeed
+type RevisionClient struct {
+	Client
+	store RevisionStore
+	now   func() time.Time
+}
+
+// NewRevisionClient returns new RevisionClient wrapping c.
+// If store is nil, FileRevisionStore in DefaultRevisionDir is used.
+func NewRevisionClient(c Client, store RevisionStore) (*RevisionClient, error) {
+	if store == nil {
+		s, err := NewFileRevisionStore("")
+		if err != nil {
+			return nil, err
+		}
+		store = s
+	}
+	return &RevisionClient{Client: c, store: store, now: time.Now}, nil
+}
+
+// update reads current spec of the service, calls f, and saves the spec as a revision if f succeeds
+func (c *RevisionClient) update(operation, id string, f func() (*ServiceData, error)) (*ServiceData, error) {
+	current, err := c.Client.ReadService(id)
+	if err != nil {
+		return nil, err
+	}
+	revision := &Revision{
+		ServiceID: id,
+		Time:      c.now(),
+		Operation: operation,
+		Spec:      current.Data.ToRequestParam(),
+	}
+
+	updated, err := f()
+	if err != nil {
+		return nil, err
+	}
+	if err := c.store.AddRevision(revision); err != nil {
+		return updated, fmt.Errorf("service is updated, but saving revision failed: %s", err)
+	}
+	return updated, nil
+}
+
+// UpdateService updates the service and saves previous spec
+func (c *RevisionClient) UpdateService(id string, param *RequestParam) (*ServiceData, error) {
+	return c.update("UpdateService", id, func() (*ServiceData, error) {
+		return c.Client.UpdateService(id, param)
+	})
+}
+
+// PatchService patches the service and saves previous spec
+func (c *RevisionClient) PatchService(ctx context.Context, id string, patch *ServicePatch) (*ServiceData, error) {
+	return c.update("PatchService", id, func() (*ServiceData, error) {
+		return c.Client.PatchService(ctx, id, patch)
+	})
+}
+
+// History returns revisions of the service with changes, in ascending order of Number
+func (c *RevisionClient) History(serviceID string) ([]*RevisionHistory, error) {
+	revisions, err := c.store.Revisions(serviceID)
+	if err != nil {
+		return nil, err
+	}
+	if len(revisions) == 0 {
+		return nil, nil
+	}
+	current, err := c.Client.ReadService(serviceID)
+	if err != nil {
+		return nil, err
+	}
+
+	var res []*RevisionHistory
+	for i, r := range revisions {
+		next := current.Data.ToRequestParam()
+		if i < len(revisions)-1 {
+			next = revisions[i+1].Spec
+		}
+		res = append(res, &RevisionHistory{Revision: r, Changes: specChanges(r.Spec, next)})
+	}
+	return res, nil
+}
+
+// Rollback updates the service with the spec of the revision.
+// The current spec is saved as a new revision, so the rollback can be also rolled back.
+func (c *RevisionClient) Rollback(ctx context.Context, serviceID string, revision int) (*ServiceData, error) {
+	revisions, err := c.store.Revisions(serviceID)
+	if err != nil {
+		return nil, err
+	}
+	var target *Revision
+	for _, r := range revisions {
+		if r.Number == revision {
+			target = r
+			break
+		}
+	}
+	if target == nil {
+		return nil, fmt.Errorf("revision %d of service %q is not found", revision, serviceID)
+	}
+	if target.Spec == nil {
+		return nil, errors.New("revision doesn't have spec")
+	}
+
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	return c.update(fmt.Sprintf("Rollback(%d)", revision), serviceID, func() (*ServiceData, error) {
+		return c.Client.UpdateService(serviceID, target.Spec)
+	})
+}
+
+// specChanges returns changes from before to after in "Field: before -> after" form
+func specChanges(before, after *RequestParam) []string {
+	var res []string
+	for _, field := range diffRequestParam(before, after) {
+		res = append(res, fmt.Sprintf("%s: %s -> %s", field, specFieldValue(before, field), specFieldValue(after, field)))
+	}
+	return res
+}
+
+func specFieldValue(p *RequestParam, field string) string {
+	switch field {
+	case "Image":
+		return fmt.Sprintf("%q", p.Image)
+	case "Command":
+		return fmt.Sprintf("%q", p.Command)
+	case "Instances":
+		return fmt.Sprintf("%d", p.Instances)
+	case "Ports":
+		return "[" + strings.Join(portStrings(p.Ports), " ") + "]"
+	case "Environment":
+		var envs []string
+		for _, e := range p.Environment {
+			value := e.Value
+			if isSecretEnvKey(e.Key, secretEnvKeys) {
+				value = redactedValue
+			}
+			envs = append(envs, e.Key+"="+value)
+		}
+		return "[" + strings.Join(envs, " ") + "]"
+	case "SubDomain":
+		return fmt.Sprintf("%q", p.SubDomain)
+	case "CustomDomains":
+		return "[" + strings.Join(p.CustomDomains, " ") + "]"
+	case "Plan":
+		region := p.Region
+		if region == "" {
+			region = RegionJPTokyo
+		}
+		return PlanID(region, p.Plan)
+	}
+	return ""
+}
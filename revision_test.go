@@ -0,0 +1,153 @@
+package arukas
+
+import (
+	"context"
+	"io/ioutil"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func newTestRevisionClient(t *testing.T) (*testClient, *RevisionClient, func()) {
+	dir, err := ioutil.TempDir("", "arukas-revision")
+	require.NoError(t, err)
+	store, err := NewFileRevisionStore(dir)
+	require.NoError(t, err)
+
+	base := newTestClient()
+	base.addApp(testAppID, testServiceID, "foo", &ServiceAttr{
+		Image:     "nginx:1.15",
+		Instances: 1,
+		Ports:     Ports{{Protocol: "tcp", Number: 80}},
+	})
+	c, err := NewRevisionClient(base, store)
+	require.NoError(t, err)
+
+	now := time.Date(2019, 1, 1, 0, 0, 0, 0, time.UTC)
+	c.now = func() time.Time {
+		now = now.Add(time.Minute)
+		return now
+	}
+	return base, c, func() { os.RemoveAll(dir) } // nolint
+}
+
+func TestRevisionClient(t *testing.T) {
+	ctx := context.Background()
+	base, c, cleanup := newTestRevisionClient(t)
+	defer cleanup()
+
+	_, err := c.PatchService(ctx, testServiceID, &ServicePatch{Instances: Int32(2)})
+	require.NoError(t, err)
+	_, err = c.PatchService(ctx, testServiceID, &ServicePatch{Image: String("nginx:1.16")})
+	require.NoError(t, err)
+
+	history, err := c.History(testServiceID)
+	require.NoError(t, err)
+	require.Len(t, history, 2)
+	assert.Equal(t, 1, history[0].Number)
+	assert.Equal(t, "PatchService", history[0].Operation)
+	assert.True(t, history[0].Time.Before(history[1].Time))
+	assert.Equal(t, []string{"Instances: 1 -> 2"}, history[0].Changes)
+	assert.Equal(t, []string{`Image: "nginx:1.15" -> "nginx:1.16"`}, history[1].Changes)
+
+	t.Run("Rollback", func(t *testing.T) {
+		_, err := c.Rollback(ctx, testServiceID, 1)
+		require.NoError(t, err)
+
+		s, err := base.ReadService(testServiceID)
+		require.NoError(t, err)
+		assert.Equal(t, "nginx:1.15", s.Data.Attributes.Image)
+		assert.Equal(t, int32(1), s.Data.Attributes.Instances)
+
+		history, err := c.History(testServiceID)
+		require.NoError(t, err)
+		require.Len(t, history, 3)
+		assert.Equal(t, "Rollback(1)", history[2].Operation)
+		assert.Equal(t, "nginx:1.16", history[2].Spec.Image)
+	})
+
+	t.Run("unknown revision", func(t *testing.T) {
+		_, err := c.Rollback(ctx, testServiceID, 100)
+		assert.Error(t, err)
+	})
+}
+
+func TestRevisionClient_ReadError(t *testing.T) {
+	base, c, cleanup := newTestRevisionClient(t)
+	defer cleanup()
+
+	// the update must not be performed if the current spec can't be saved
+	base.errors["ReadService"] = ErrorNotFound(assert.AnError)
+	_, err := c.UpdateService(testServiceID, &RequestParam{Image: "redis"})
+	assert.Error(t, err)
+	assert.Equal(t, 0, base.called("UpdateService"))
+
+	history, err := c.History(testServiceID)
+	assert.NoError(t, err)
+	assert.Empty(t, history)
+}
+
+func TestRevisionClient_UpdateError(t *testing.T) {
+	ctx := context.Background()
+	base, c, cleanup := newTestRevisionClient(t)
+	defer cleanup()
+
+	// failed update must not be saved as a revision
+	base.errors["PatchService"] = assert.AnError
+	_, err := c.PatchService(ctx, testServiceID, &ServicePatch{Instances: Int32(2)})
+	assert.Error(t, err)
+
+	history, err := c.History(testServiceID)
+	assert.NoError(t, err)
+	assert.Empty(t, history)
+}
+
+func TestRevisionClient_RedactChanges(t *testing.T) {
+	ctx := context.Background()
+	_, c, cleanup := newTestRevisionClient(t)
+	defer cleanup()
+
+	_, err := c.PatchService(ctx, testServiceID, &ServicePatch{
+		Environment: []*Env{{Key: "DB_PASSWORD", Value: "secret"}, {Key: "MODE", Value: "prod"}},
+	})
+	require.NoError(t, err)
+
+	history, err := c.History(testServiceID)
+	require.NoError(t, err)
+	require.Len(t, history, 1)
+	assert.Equal(t, []string{"Environment: [] -> [DB_PASSWORD=[REDACTED] MODE=prod]"}, history[0].Changes)
+}
+
+func TestFileRevisionStore(t *testing.T) {
+	dir, err := ioutil.TempDir("", "arukas-revision")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir) // nolint
+
+	store, err := NewFileRevisionStore(dir)
+	require.NoError(t, err)
+
+	for i := 0; i < 2; i++ {
+		r := &Revision{ServiceID: testServiceID, Spec: &RequestParam{Instances: int32(i)}}
+		require.NoError(t, store.AddRevision(r))
+		assert.Equal(t, i+1, r.Number)
+	}
+
+	// a new store reads revisions saved by another one
+	store, err = NewFileRevisionStore(dir)
+	require.NoError(t, err)
+	revisions, err := store.Revisions(testServiceID)
+	require.NoError(t, err)
+	require.Len(t, revisions, 2)
+	assert.Equal(t, int32(1), revisions[1].Spec.Instances)
+
+	revisions, err = store.Revisions(testAnotherSvcID)
+	assert.NoError(t, err)
+	assert.Empty(t, revisions)
+
+	for _, id := range []string{"", "../foo"} {
+		assert.Error(t, store.AddRevision(&Revision{ServiceID: id}))
+	}
+}
@@ -0,0 +1,101 @@
+package arukas
+
+import (
+	"context"
+	"time"
+)
+
+const defaultScalePollInterval = 5 * time.Second
+
+// ScaleOption represents options of Scale
+type ScaleOption struct {
+	// Wait blocks until PortMappings has one entry per instance.
+	// Waiting is skipped if the service is not running.
+	Wait bool
+	// PollInterval is interval to read service while waiting. default: 5 seconds
+	PollInterval time.Duration
+}
+
+// Scale changes the number of instances of the service.
+// instances is validated against the max instances of the service plan,
+// or 1..10 if the plan is unknown. Other attributes are preserved.
+func Scale(ctx context.Context, c Client, serviceID string, instances int32, opt *ScaleOption) (*ServiceData, error) {
+	if err := validateRequired("ServiceID", serviceID); err != nil {
+		return nil, err
+	}
+	if err := validateID("ServiceID", serviceID); err != nil {
+		return nil, err
+	}
+
+	current, err := c.ReadService(serviceID)
+	if err != nil {
+		return nil, err
+	}
+
+	maxInstances := defaultMaxInstances
+	plan, err := findServicePlan(ctx, c, current.Data)
+	if err != nil {
+		return nil, err
+	}
+	if plan != nil {
+		maxInstances = int(plan.MaxInstances)
+	}
+	if err := validateRange("Instances", int(instances), 1, maxInstances); err != nil {
+		return nil, err
+	}
+
+	updated := current
+	if current.Instances() != instances {
+		updated, err = c.PatchService(ctx, serviceID, &ServicePatch{Instances: Int32(instances)})
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	if opt == nil || !opt.Wait || updated.Status() != StatusRunning {
+		return updated, nil
+	}
+	return waitForInstances(ctx, c, serviceID, instances, opt.PollInterval)
+}
+
+// findServicePlan returns plan of the service from ListPlans, returns nil if unknown
+func findServicePlan(ctx context.Context, c Client, s *Service) (*Plan, error) {
+	if s == nil || !s.hasPlanID() {
+		return nil, nil
+	}
+	plans, err := c.ListPlans(ctx)
+	if err != nil {
+		return nil, err
+	}
+	for _, p := range plans {
+		if p.ID == s.PlanID() {
+			return p, nil
+		}
+	}
+	return nil, nil
+}
+
+// waitForInstances blocks until PortMappings of the service has specified number of entries
+func waitForInstances(ctx context.Context, c Client, serviceID string, instances int32, interval time.Duration) (*ServiceData, error) {
+	if interval <= 0 {
+		interval = defaultScalePollInterval
+	}
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		s, err := c.ReadService(serviceID)
+		if err != nil {
+			return nil, err
+		}
+		if len(s.PortMappings()) == int(instances) {
+			return s, nil
+		}
+
+		select {
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		case <-ticker.C:
+		}
+	}
+}
@@ -0,0 +1,74 @@
+package arukas
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestScale(t *testing.T) {
+	ctx := context.Background()
+
+	t.Run("Exceeds plan limit", func(t *testing.T) {
+		c := newTestClient()
+		c.addApp(testAppID, testServiceID, "foo", &ServiceAttr{Image: "nginx", Instances: 1})
+
+		_, err := Scale(ctx, c, testServiceID, 2, nil)
+		assert.Error(t, err)
+		assert.Equal(t, "Instances", ValidationErrors(err)[0].Field)
+		assert.Equal(t, 0, c.called("PatchService"))
+	})
+
+	t.Run("Unknown plan", func(t *testing.T) {
+		c := newTestClient()
+		s := c.addApp(testAppID, testServiceID, "foo", &ServiceAttr{Image: "nginx", Instances: 1})
+		s.Relationships.ServicePlan.Data.ID = "foo/bar"
+
+		_, err := Scale(ctx, c, testServiceID, 10, nil)
+		assert.NoError(t, err)
+		_, err = Scale(ctx, c, testServiceID, 11, nil)
+		assert.Error(t, err)
+	})
+
+	t.Run("Preserve other attributes", func(t *testing.T) {
+		c := newTestClient()
+		s := c.addApp(testAppID, testServiceID, "foo", &ServiceAttr{
+			Image:       "nginx",
+			Instances:   1,
+			Environment: []*Env{{Key: "FOO", Value: "bar"}},
+		})
+		s.Relationships.ServicePlan.Data.ID = PlanID(RegionJPTokyo, PlanHobby)
+
+		res, err := Scale(ctx, c, testServiceID, 3, nil)
+		assert.NoError(t, err)
+		assert.Equal(t, int32(3), res.Instances())
+		assert.Equal(t, "nginx", res.Image())
+		assert.Len(t, res.Environment(), 1)
+	})
+
+	t.Run("Wait for port mappings", func(t *testing.T) {
+		c := newTestClient()
+		s := c.addApp(testAppID, testServiceID, "foo", &ServiceAttr{
+			Image:        "nginx",
+			Instances:    1,
+			Status:       StatusRunning,
+			PortMappings: [][]*PortMapping{{{Host: "host1"}}},
+		})
+		s.Relationships.ServicePlan.Data.ID = PlanID(RegionJPTokyo, PlanHobby)
+
+		go func() {
+			time.Sleep(50 * time.Millisecond)
+			c.updateAttr(testServiceID, func(attr *ServiceAttr) {
+				attr.PortMappings = [][]*PortMapping{{{Host: "host1"}}, {{Host: "host2"}}}
+			})
+		}()
+
+		ctx, cancel := context.WithTimeout(ctx, time.Second)
+		defer cancel()
+		res, err := Scale(ctx, c, testServiceID, 2, &ScaleOption{Wait: true, PollInterval: 10 * time.Millisecond})
+		assert.NoError(t, err)
+		assert.Len(t, res.PortMappings(), 2)
+	})
+}
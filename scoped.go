@@ -0,0 +1,298 @@
+package arukas
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"regexp"
+)
+
+// Scope represents apps which Scoped client can access.
+// An app is in scope if its ID is in AppIDs or its name matches NamePattern.
+// A service is in scope if its app is in scope.
+type Scope struct {
+	AppIDs      []string
+	NamePattern *regexp.Regexp
+}
+
+// containsApp returns true if the app is in scope
+func (s *Scope) containsApp(app *App) bool {
+	if app == nil {
+		return false
+	}
+	return s.containsAppID(app.ID) || (app.Attributes != nil && s.matchName(app.Name()))
+}
+
+func (s *Scope) containsAppID(id string) bool {
+	for _, v := range s.AppIDs {
+		if v == id {
+			return true
+		}
+	}
+	return false
+}
+
+func (s *Scope) matchName(name string) bool {
+	return s.NamePattern != nil && s.NamePattern.MatchString(name)
+}
+
+// scopedClient hides resources out of scope.
+// Client is not embedded so that a new method of Client must be scoped here explicitly.
+type scopedClient struct {
+	client Client
+	scope  Scope
+}
+
+// Scoped returns Client which can access only apps in scope and their services.
+// Resources out of scope are excluded from ListApps/ListServices, and both reads and mutations of them
+// return the same ErrorNotFound as nonexistent resources, so that their existence is not revealed.
+// New apps can be created only when their names match scope.NamePattern, otherwise *ForbiddenError is returned.
+func Scoped(c Client, scope Scope) (Client, error) {
+	if len(scope.AppIDs) == 0 && scope.NamePattern == nil {
+		return nil, errors.New("scope must have AppIDs or NamePattern")
+	}
+	for i, id := range scope.AppIDs {
+		if err := validateID(fieldPath("AppIDs", i, ""), id); err != nil {
+			return nil, err
+		}
+	}
+	return &scopedClient{client: c, scope: scope}, nil
+}
+
+// notFound returns the error for both nonexistent resources and resources out of scope
+func (c *scopedClient) notFound(typ, id string) error {
+	return ErrorNotFound(fmt.Errorf("%s %q is not found", typ, id))
+}
+
+// readApp returns the app, ok is false if the app is out of scope
+func (c *scopedClient) readApp(id string) (app *AppData, ok bool, err error) {
+	app, err = c.client.ReadApp(id)
+	if _, isNotFound := err.(*httpNotFoundError); isNotFound {
+		return nil, false, c.notFound(TypeApps, id)
+	}
+	if err != nil {
+		return nil, false, err
+	}
+	return app, c.scope.containsApp(app.Data), nil
+}
+
+// readService returns the service, ok is false if the service is out of scope
+func (c *scopedClient) readService(id string) (s *ServiceData, ok bool, err error) {
+	s, err = c.client.ReadService(id)
+	if _, isNotFound := err.(*httpNotFoundError); isNotFound {
+		return nil, false, c.notFound(TypeServices, id)
+	}
+	if err != nil {
+		return nil, false, err
+	}
+	if s.Data == nil || s.Data.Attributes == nil {
+		return s, false, nil
+	}
+	if c.scope.containsAppID(s.AppID()) {
+		return s, true, nil
+	}
+	if c.scope.NamePattern == nil {
+		return s, false, nil
+	}
+	_, ok, err = c.readApp(s.AppID())
+	return s, ok, err
+}
+
+// checkApp returns ErrorNotFound if the app is out of scope
+func (c *scopedClient) checkApp(id string) error {
+	_, ok, err := c.readApp(id)
+	if err != nil {
+		return err
+	}
+	if !ok {
+		return c.notFound(TypeApps, id)
+	}
+	return nil
+}
+
+// checkService returns ErrorNotFound if the service is out of scope
+func (c *scopedClient) checkService(id string) error {
+	_, ok, err := c.readService(id)
+	if err != nil {
+		return err
+	}
+	if !ok {
+		return c.notFound(TypeServices, id)
+	}
+	return nil
+}
+
+func (c *scopedClient) ListApps() (*AppListData, error) {
+	list, err := c.client.ListApps()
+	if err != nil {
+		return nil, err
+	}
+
+	res := &AppListData{}
+	serviceIDs := map[string]bool{}
+	for _, app := range list.Data {
+		if !c.scope.containsApp(app) {
+			continue
+		}
+		res.Data = append(res.Data, app)
+		for _, id := range app.ServiceIDs() {
+			serviceIDs[id] = true
+		}
+	}
+	for _, v := range list.Included {
+		if serviceIDs[includedID(v)] {
+			res.Included = append(res.Included, v)
+		}
+	}
+	return res, nil
+}
+
+// includedID returns id of an included resource
+func includedID(v interface{}) string {
+	var resource struct {
+		ID string `json:"id"`
+	}
+	data, err := json.Marshal(v)
+	if err != nil {
+		return ""
+	}
+	if err := json.Unmarshal(data, &resource); err != nil {
+		return ""
+	}
+	return resource.ID
+}
+
+func (c *scopedClient) ReadApp(id string) (*AppData, error) {
+	app, ok, err := c.readApp(id)
+	if err != nil {
+		return nil, err
+	}
+	if !ok {
+		return nil, c.notFound(TypeApps, id)
+	}
+	return app, nil
+}
+
+func (c *scopedClient) CreateApp(param *RequestParam) (*AppData, error) {
+	if param == nil {
+		return nil, errors.New("param is nil")
+	}
+	if !c.scope.matchName(param.Name) {
+		return nil, &ForbiddenError{Method: "CreateApp", ID: param.Name, Reason: "app name doesn't match scope"}
+	}
+	return c.client.CreateApp(param)
+}
+
+func (c *scopedClient) CreateAppWithSpec(spec *AppSpec) (*AppData, error) {
+	if spec == nil {
+		return nil, errors.New("spec is nil")
+	}
+	if !c.scope.matchName(spec.Name) {
+		return nil, &ForbiddenError{Method: "CreateAppWithSpec", ID: spec.Name, Reason: "app name doesn't match scope"}
+	}
+	return c.client.CreateAppWithSpec(spec)
+}
+
+func (c *scopedClient) UpdateApp(ctx context.Context, id string, patch AppPatch) (*AppData, error) {
+	if err := c.checkApp(id); err != nil {
+		return nil, err
+	}
+	// renaming must not move the app out of scope
+	if patch.Name != nil && !c.scope.containsAppID(id) && !c.scope.matchName(*patch.Name) {
+		return nil, &ForbiddenError{Method: "UpdateApp", ID: id, Reason: "app name doesn't match scope"}
+	}
+	return c.client.UpdateApp(ctx, id, patch)
+}
+
+func (c *scopedClient) DeleteApp(id string) error {
+	if err := c.checkApp(id); err != nil {
+		return err
+	}
+	return c.client.DeleteApp(id)
+}
+
+func (c *scopedClient) ListServices() (*ServiceListData, error) {
+	list, err := c.client.ListServices()
+	if err != nil {
+		return nil, err
+	}
+
+	appIDs := map[string]bool{}
+	for _, id := range c.scope.AppIDs {
+		appIDs[id] = true
+	}
+	if c.scope.NamePattern != nil {
+		apps, err := c.client.ListApps()
+		if err != nil {
+			return nil, err
+		}
+		for _, app := range apps.Data {
+			if c.scope.containsApp(app) {
+				appIDs[app.ID] = true
+			}
+		}
+	}
+
+	res := &ServiceListData{}
+	for _, s := range list.Data {
+		if s.Attributes != nil && appIDs[s.AppID()] {
+			res.Data = append(res.Data, s)
+		}
+	}
+	return res, nil
+}
+
+func (c *scopedClient) ReadService(id string) (*ServiceData, error) {
+	s, ok, err := c.readService(id)
+	if err != nil {
+		return nil, err
+	}
+	if !ok {
+		return nil, c.notFound(TypeServices, id)
+	}
+	return s, nil
+}
+
+func (c *scopedClient) UpdateService(id string, param *RequestParam) (*ServiceData, error) {
+	if err := c.checkService(id); err != nil {
+		return nil, err
+	}
+	return c.client.UpdateService(id, param)
+}
+
+func (c *scopedClient) PatchService(ctx context.Context, id string, patch *ServicePatch) (*ServiceData, error) {
+	if err := c.checkService(id); err != nil {
+		return nil, err
+	}
+	return c.client.PatchService(ctx, id, patch)
+}
+
+func (c *scopedClient) PowerOn(id string) error {
+	if err := c.checkService(id); err != nil {
+		return err
+	}
+	return c.client.PowerOn(id)
+}
+
+func (c *scopedClient) PowerOff(id string) error {
+	if err := c.checkService(id); err != nil {
+		return err
+	}
+	return c.client.PowerOff(id)
+}
+
+func (c *scopedClient) WaitForState(ctx context.Context, serviceID string, status string) error {
+	if _, err := c.ReadService(serviceID); err != nil {
+		return err
+	}
+	return c.client.WaitForState(ctx, serviceID, status)
+}
+
+func (c *scopedClient) ListPlans(ctx context.Context) ([]*Plan, error) {
+	return c.client.ListPlans(ctx)
+}
+
+func (c *scopedClient) Version() string {
+	return c.client.Version()
+}
@@ -0,0 +1,108 @@
+package arukas
+
+import (
+	"context"
+	"regexp"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func newScopedTestClient() *testClient {
+	c := newTestClient()
+	c.addApp(testAppID, testServiceID, "team-a-web", &ServiceAttr{Image: "nginx", Instances: 1})
+	c.addApp(testAnotherAppID, testAnotherSvcID, "team-b-web", &ServiceAttr{Image: "nginx", Instances: 1})
+	return c
+}
+
+func TestScoped(t *testing.T) {
+	ctx := context.Background()
+
+	t.Run("Invalid scope", func(t *testing.T) {
+		_, err := Scoped(newTestClient(), Scope{})
+		assert.Error(t, err)
+		_, err = Scoped(newTestClient(), Scope{AppIDs: []string{"foo"}})
+		assert.Error(t, err)
+	})
+
+	scopes := []struct {
+		scenario string
+		scope    Scope
+	}{
+		{
+			scenario: "AppIDs",
+			scope:    Scope{AppIDs: []string{testAppID}},
+		},
+		{
+			scenario: "NamePattern",
+			scope:    Scope{NamePattern: regexp.MustCompile(`^team-a-`)},
+		},
+	}
+
+	for _, s := range scopes {
+		t.Run(s.scenario, func(t *testing.T) {
+			base := newScopedTestClient()
+			c, err := Scoped(base, s.scope)
+			assert.NoError(t, err)
+
+			apps, err := c.ListApps()
+			assert.NoError(t, err)
+			assert.Len(t, apps.Data, 1)
+			assert.Equal(t, testAppID, apps.Data[0].ID)
+
+			services, err := c.ListServices()
+			assert.NoError(t, err)
+			assert.Len(t, services.Data, 1)
+			assert.Equal(t, testServiceID, services.Data[0].ID)
+
+			_, err = c.ReadApp(testAppID)
+			assert.NoError(t, err)
+			_, err = c.ReadApp(testAnotherAppID)
+			assert.EqualError(t, err, `apps "`+testAnotherAppID+`" is not found`)
+			_, err = c.ReadService(testAnotherSvcID)
+			assert.EqualError(t, err, `services "`+testAnotherSvcID+`" is not found`)
+			assert.Error(t, c.WaitForState(ctx, testAnotherSvcID, StatusStopped))
+
+			// out of scope resources are indistinguishable from nonexistent ones
+			unknownAppID := "00000000-0000-0000-0000-000000000000"
+			_, err = c.ReadApp(unknownAppID)
+			assert.EqualError(t, err, `apps "`+unknownAppID+`" is not found`)
+			_, err = c.ReadService(unknownAppID)
+			assert.EqualError(t, err, `services "`+unknownAppID+`" is not found`)
+
+			assert.NoError(t, c.PowerOn(testServiceID))
+			assert.EqualError(t, c.PowerOn(testAnotherSvcID), `services "`+testAnotherSvcID+`" is not found`)
+			assert.EqualError(t, c.DeleteApp(testAnotherAppID), `apps "`+testAnotherAppID+`" is not found`)
+			_, err = c.PatchService(ctx, testAnotherSvcID, &ServicePatch{Image: String("nginx:2")})
+			assert.EqualError(t, err, `services "`+testAnotherSvcID+`" is not found`)
+			assert.Equal(t, 1, base.called("PowerOn"))
+			assert.Equal(t, 0, base.called("DeleteApp"))
+			assert.Equal(t, 0, base.called("PatchService"))
+		})
+	}
+
+	t.Run("Create and rename", func(t *testing.T) {
+		base := newScopedTestClient()
+		c, err := Scoped(base, Scope{NamePattern: regexp.MustCompile(`^team-a-`)})
+		assert.NoError(t, err)
+
+		param := &RequestParam{
+			Name:      "team-b-db",
+			Image:     "postgres",
+			Instances: 1,
+			Ports:     Ports{{Number: 5432, Protocol: "tcp"}},
+			Plan:      PlanFree,
+		}
+		_, err = c.CreateApp(param)
+		assert.IsType(t, &ForbiddenError{}, err)
+
+		param.Name = "team-a-db"
+		_, err = c.CreateApp(param)
+		assert.NoError(t, err)
+
+		_, err = c.UpdateApp(ctx, testAppID, AppPatch{Name: String("team-b-app")})
+		assert.IsType(t, &ForbiddenError{}, err)
+		_, err = c.UpdateApp(ctx, testAppID, AppPatch{Name: String("team-a-app")})
+		assert.NoError(t, err)
+	})
+}
@@ -1,6 +1,9 @@
 package arukas
 
-import "time"
+import (
+	"strings"
+	"time"
+)
 
 // ServiceListData represents services data
 type ServiceListData struct {
@@ -99,25 +102,59 @@ func (s *Service) PlanID() string {
 	return s.Relationships.ServicePlan.Data.ID
 }
 
+// hasPlanID returns true if data.relationship.service_plan.data exists
+func (s *Service) hasPlanID() bool {
+	return s.Relationships != nil && s.Relationships.ServicePlan != nil && s.Relationships.ServicePlan.Data != nil
+}
+
+// ToRequestParam returns *RequestParam built from current spec of the service.
+// Name is empty because service doesn't have app name.
+// Spec is empty if the service doesn't have attributes.
+func (s *Service) ToRequestParam() *RequestParam {
+	attr := s.Attributes
+	if attr == nil {
+		attr = &ServiceAttr{}
+	}
+	p := &RequestParam{
+		Command:     attr.Command,
+		Image:       attr.Image,
+		Instances:   attr.Instances,
+		Ports:       attr.Ports,
+		Environment: attr.Environment,
+		SubDomain:   attr.SubDomain,
+	}
+	for _, d := range attr.CustomDomains {
+		if d != nil {
+			p.CustomDomains = append(p.CustomDomains, d.Name)
+		}
+	}
+	if s.hasPlanID() {
+		if parts := strings.SplitN(s.PlanID(), "/", 2); len(parts) == 2 {
+			p.Region, p.Plan = parts[0], parts[1]
+		}
+	}
+	return p
+}
+
 // ServiceAttr represents service.attributes object
 type ServiceAttr struct {
-	AppID                    string           `json:"app-id,omitempty"`
-	Image                    string           `json:"image"`
-	Command                  string           `json:"command"`
-	Instances                int32            `json:"instances"`
-	CPUs                     float32          `json:"cups,omitempty"`
-	Memory                   int32            `json:"memory,omitempty"`
-	Environment              []*Env           `json:"environment"`
-	Ports                    Ports            `json:"ports,omitempty"`
-	PortMappings             [][]*PortMapping `json:"port-mappings,omitempty"`
-	CreatedAt                *time.Time       `json:"created-at,omitempty"`
-	UpdatedAt                *time.Time       `json:"updated-at,omitempty"`
-	Status                   string           `json:"status,omitempty"`
-	SubDomain                string           `json:"subdomain,omitempty"`
-	EndPoint                 string           `json:"endpoint,omitempty"`
-	CustomDomains            []*CustomDomain  `json:"custom-domains,omitempty"`
-	LastInstanceFailedAt     *time.Time       `json:"last-instance-failed-at,omitempty"`
-	LastInstanceFailedStatus interface{}      `json:"last-instance-failed-status,omitempty"`
+	AppID                    string                `json:"app-id,omitempty"`
+	Image                    string                `json:"image"`
+	Command                  string                `json:"command"`
+	Instances                int32                 `json:"instances"`
+	CPUs                     float32               `json:"cups,omitempty"`
+	Memory                   int32                 `json:"memory,omitempty"`
+	Environment              []*Env                `json:"environment"`
+	Ports                    Ports                 `json:"ports,omitempty"`
+	PortMappings             [][]*PortMapping      `json:"port-mappings,omitempty"`
+	CreatedAt                *time.Time            `json:"created-at,omitempty"`
+	UpdatedAt                *time.Time            `json:"updated-at,omitempty"`
+	Status                   string                `json:"status,omitempty"`
+	SubDomain                string                `json:"subdomain,omitempty"`
+	EndPoint                 string                `json:"endpoint,omitempty"`
+	CustomDomains            []*CustomDomain       `json:"custom-domains,omitempty"`
+	LastInstanceFailedAt     *time.Time            `json:"last-instance-failed-at,omitempty"`
+	LastInstanceFailedStatus *InstanceFailedStatus `json:"last-instance-failed-status,omitempty"`
 }
 
 // ServiceData represents service data
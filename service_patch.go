@@ -0,0 +1,190 @@
+package arukas
+
+import (
+	"errors"
+
+	"github.com/hashicorp/go-multierror"
+)
+
+// ServicePatch represents parameter to update only specified service attributes.
+// Fields with nil value are not sent to the API, so they are left unchanged.
+type ServicePatch struct {
+	Image     *string
+	Command   *string
+	Instances *int32
+	// Ports replaces all ports when not nil
+	Ports Ports
+	// Environment replaces all environment variables when not nil.
+	// Empty non-nil slice removes all of them.
+	Environment []*Env
+	SubDomain   *string
+	// CustomDomains replaces all custom domains when not nil.
+	// Empty non-nil slice removes all of them.
+	CustomDomains []string
+	Region        *string
+	Plan          *string
+}
+
+// String returns a pointer to the string value, helper for ServicePatch
+func String(v string) *string {
+	return &v
+}
+
+// Int32 returns a pointer to the int32 value, helper for ServicePatch
+func Int32(v int32) *int32 {
+	return &v
+}
+
+// servicePatchData represents service data which has only specified attributes
+type servicePatchData struct {
+	Data *servicePatchBody `json:"data"`
+}
+
+type servicePatchBody struct {
+	Attributes    *servicePatchAttr    `json:"attributes,omitempty"`
+	Relationships *ServiceRelationship `json:"relationships,omitempty"`
+}
+
+type servicePatchAttr struct {
+	Image         *string          `json:"image,omitempty"`
+	Command       *string          `json:"command,omitempty"`
+	Instances     *int32           `json:"instances,omitempty"`
+	Ports         *Ports           `json:"ports,omitempty"`
+	Environment   *[]*Env          `json:"environment,omitempty"`
+	SubDomain     *string          `json:"subdomain,omitempty"`
+	CustomDomains *[]*CustomDomain `json:"custom-domains,omitempty"`
+}
+
+// isEmpty returns true if no fields are specified
+func (p *ServicePatch) isEmpty() bool {
+	return p.Image == nil && p.Command == nil && p.Instances == nil &&
+		p.Ports == nil && p.Environment == nil && p.SubDomain == nil &&
+		p.CustomDomains == nil && p.Region == nil && p.Plan == nil
+}
+
+// requestParam returns *RequestParam which has only specified fields
+func (p *ServicePatch) requestParam() *RequestParam {
+	param := &RequestParam{
+		Ports:         p.Ports,
+		Environment:   p.Environment,
+		CustomDomains: p.CustomDomains,
+	}
+	if p.Image != nil {
+		param.Image = *p.Image
+	}
+	if p.Command != nil {
+		param.Command = *p.Command
+	}
+	if p.Instances != nil {
+		param.Instances = *p.Instances
+	}
+	if p.SubDomain != nil {
+		param.SubDomain = *p.SubDomain
+	}
+	if p.Region != nil {
+		param.Region = *p.Region
+	}
+	if p.Plan != nil {
+		param.Plan = *p.Plan
+	}
+	return param
+}
+
+// applyTo returns a copy of param which specified fields are overwritten
+func (p *ServicePatch) applyTo(param *RequestParam) *RequestParam {
+	res := *param
+	patch := p.requestParam()
+	if p.Image != nil {
+		res.Image = patch.Image
+	}
+	if p.Command != nil {
+		res.Command = patch.Command
+	}
+	if p.Instances != nil {
+		res.Instances = patch.Instances
+	}
+	if p.Ports != nil {
+		res.Ports = patch.Ports
+	}
+	if p.Environment != nil {
+		res.Environment = patch.Environment
+	}
+	if p.SubDomain != nil {
+		res.SubDomain = patch.SubDomain
+	}
+	if p.CustomDomains != nil {
+		res.CustomDomains = patch.CustomDomains
+	}
+	if p.Plan != nil {
+		res.Region, res.Plan = patch.Region, patch.Plan
+	}
+	return &res
+}
+
+// Validate returns error if specified fields are invalid
+func (p *ServicePatch) Validate() error {
+	if p.isEmpty() {
+		return errors.New("ServicePatch has no fields to update")
+	}
+
+	var results error
+	param := p.requestParam()
+
+	if p.Image != nil {
+		if err := validateRequired("Image", param.Image); err != nil {
+			results = multierror.Append(results, err)
+		}
+	}
+	if p.Instances != nil && param.Instances <= 0 {
+		// validateOptionals skips zero value, so check it here
+		results = multierror.Append(results, outOfRangeError("Instances", 1, defaultMaxInstances))
+	}
+	if p.Ports != nil && len(p.Ports) == 0 {
+		results = multierror.Append(results, requiredError("Ports"))
+	}
+	if p.Region != nil && p.Plan == nil {
+		results = multierror.Append(results, requiredError("Plan"))
+	}
+	if p.Plan != nil {
+		if err := validateRequired("Plan", param.Plan); err != nil {
+			results = multierror.Append(results, err)
+		}
+	}
+
+	if err := param.validateOptionals(); err != nil {
+		results = multierror.Append(results, err)
+	}
+
+	return results
+}
+
+// toServicePatchData returns *servicePatchData built from ServicePatch
+func (p *ServicePatch) toServicePatchData() *servicePatchData {
+	attr := &servicePatchAttr{
+		Image:     p.Image,
+		Command:   p.Command,
+		Instances: p.Instances,
+		SubDomain: p.SubDomain,
+	}
+	if p.Ports != nil {
+		attr.Ports = &p.Ports
+	}
+	if p.Environment != nil {
+		env := p.Environment
+		attr.Environment = &env
+	}
+	if p.CustomDomains != nil {
+		domains := CustomDomains(p.CustomDomains...)
+		attr.CustomDomains = &domains
+	}
+
+	body := &servicePatchBody{Attributes: attr}
+	if p.Plan != nil {
+		region := RegionJPTokyo
+		if p.Region != nil {
+			region = *p.Region
+		}
+		body.Relationships = NewServiceRelationship(region, *p.Plan)
+	}
+	return &servicePatchData{Data: body}
+}
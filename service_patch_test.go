@@ -0,0 +1,108 @@
+package arukas
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestServicePatch_Validate(t *testing.T) {
+
+	expects := []struct {
+		scenario string
+		expect   bool
+		patch    *ServicePatch
+	}{
+		{
+			scenario: "No fields",
+			expect:   false,
+			patch:    &ServicePatch{},
+		},
+		{
+			scenario: "Only instances",
+			expect:   true,
+			patch:    &ServicePatch{Instances: Int32(2)},
+		},
+		{
+			scenario: "Zero instances",
+			expect:   false,
+			patch:    &ServicePatch{Instances: Int32(0)},
+		},
+		{
+			scenario: "Empty image",
+			expect:   false,
+			patch:    &ServicePatch{Image: String("")},
+		},
+		{
+			scenario: "Empty ports",
+			expect:   false,
+			patch:    &ServicePatch{Ports: Ports{}},
+		},
+		{
+			scenario: "Empty environment",
+			expect:   true,
+			patch:    &ServicePatch{Environment: []*Env{}},
+		},
+		{
+			scenario: "Region without plan",
+			expect:   false,
+			patch:    &ServicePatch{Region: String(RegionJPTokyo)},
+		},
+		{
+			scenario: "Instances exceeds plan limit",
+			expect:   false,
+			patch:    &ServicePatch{Instances: Int32(2), Plan: String(PlanFree)},
+		},
+	}
+
+	for _, expect := range expects {
+		t.Run(expect.scenario, func(t *testing.T) {
+			err := expect.patch.Validate()
+			assert.Equal(t, expect.expect, err == nil)
+		})
+	}
+}
+
+func TestServicePatch_ToServicePatchData(t *testing.T) {
+
+	expects := []struct {
+		scenario string
+		patch    *ServicePatch
+		json     string
+	}{
+		{
+			scenario: "Instances",
+			patch:    &ServicePatch{Instances: Int32(2)},
+			json:     `{"data": {"attributes": {"instances": 2}}}`,
+		},
+		{
+			scenario: "Clear environment and custom domains",
+			patch:    &ServicePatch{Environment: []*Env{}, CustomDomains: []string{}},
+			json:     `{"data": {"attributes": {"environment": [], "custom-domains": []}}}`,
+		},
+		{
+			scenario: "Image and ports",
+			patch: &ServicePatch{
+				Image: String("nginx:latest"),
+				Ports: Ports{{Protocol: "tcp", Number: 80}},
+			},
+			json: `{"data": {"attributes": {"image": "nginx:latest", "ports": ["80/tcp"]}}}`,
+		},
+		{
+			scenario: "Plan",
+			patch:    &ServicePatch{Plan: String(PlanHobby)},
+			json: `{"data": {"attributes": {}, "relationships": {
+				"service-plan": {"data": {"id": "jp-tokyo/hobby", "type": "service-plans"}}
+			}}}`,
+		},
+	}
+
+	for _, expect := range expects {
+		t.Run(expect.scenario, func(t *testing.T) {
+			data, err := json.Marshal(expect.patch.toServicePatchData())
+			assert.NoError(t, err)
+			assert.JSONEq(t, expect.json, string(data))
+		})
+	}
+}
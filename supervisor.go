@@ -0,0 +1,312 @@
+package arukas
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+)
+
+const (
+	defaultSupervisorInterval  = 30 * time.Second
+	defaultCrashLoopWindow     = 10 * time.Minute
+	defaultCrashLoopThreshold  = 3
+	defaultRemediationBackoff  = time.Minute
+	defaultRemediationMaxDelay = 30 * time.Minute
+	defaultMaxRemediations     = 5
+)
+
+// Remediation represents action applied to a service in crash loop
+type Remediation int
+
+const (
+	// RemediationAlert only calls SupervisorParam.OnCrashLoop
+	RemediationAlert Remediation = iota
+	// RemediationPowerCycle powers off and powers on the service
+	RemediationPowerCycle
+	// RemediationRollback updates the service to the last known-good spec
+	RemediationRollback
+)
+
+// String returns name of the remediation
+func (r Remediation) String() string {
+	switch r {
+	case RemediationAlert:
+		return "alert"
+	case RemediationPowerCycle:
+		return "power-cycle"
+	case RemediationRollback:
+		return "rollback"
+	default:
+		return "unknown"
+	}
+}
+
+// errNoKnownGoodSpec is returned when rollback is requested before known-good spec is observed
+var errNoKnownGoodSpec = errors.New("known-good spec is not observed yet")
+
+// SupervisorParam represents parameters of Supervisor
+type SupervisorParam struct {
+	// ServiceIDs are IDs of services to watch
+	ServiceIDs []string
+	// Interval is interval to read services. default: 30 seconds
+	Interval time.Duration
+	// Window is time window to count failures. default: 10 minutes
+	// A spec which runs without failure for Window is treated as known-good.
+	Window time.Duration
+	// Threshold is number of failures within Window to detect crash loop. default: 3
+	Threshold int
+	// Remediation is an action applied to the service in crash loop. default: RemediationAlert
+	Remediation Remediation
+	// Backoff is minimum interval between remediations of same service.
+	// It is doubled for each consecutive remediation up to MaxBackoff. default: 1 minute
+	Backoff time.Duration
+	// MaxBackoff is upper limit of Backoff. default: 30 minutes
+	MaxBackoff time.Duration
+	// MaxRemediations is max number of consecutive remediations of same service.
+	// Counter is reset when the service runs without failure for Window. default: 5
+	MaxRemediations int
+	// OnCrashLoop is called when crash loop is detected, after remediation is applied or skipped
+	OnCrashLoop func(event *CrashLoopEvent)
+	// OnError is called when reading service is failed or the service has no attributes
+	OnError func(serviceID string, err error)
+}
+
+// CrashLoopEvent represents detected crash loop and result of remediation
+type CrashLoopEvent struct {
+	ServiceID string
+	// Failures are LastInstanceFailedAt values observed within Window
+	Failures    []time.Time
+	LastFailure *FailureInfo
+	Remediation Remediation
+	// Skipped is true if remediation was not applied because of backoff or MaxRemediations
+	Skipped bool
+	// Err is an error of remediation
+	Err error
+}
+
+// Supervisor watches services and remediates services in crash loop
+type Supervisor struct {
+	client Client
+	param  SupervisorParam
+	now    func() time.Time
+
+	mu     sync.Mutex
+	states map[string]*supervisedService
+}
+
+// supervisedService represents state of a watched service
+type supervisedService struct {
+	observed     bool
+	lastFailedAt *time.Time
+	failures     []time.Time
+
+	// candidate is a spec being observed, it becomes knownGood after running Window without failure
+	candidate      *RequestParam
+	candidateSince time.Time
+	knownGood      *RequestParam
+
+	remediations int
+	backoff      time.Duration
+	nextAllowed  time.Time
+}
+
+// NewSupervisor returns new Supervisor
+func NewSupervisor(c Client, p *SupervisorParam) (*Supervisor, error) {
+	if p == nil {
+		return nil, errors.New("SupervisorParam is nil")
+	}
+	if err := validateRequired("ServiceIDs", p.ServiceIDs); err != nil {
+		return nil, err
+	}
+	for _, id := range p.ServiceIDs {
+		if err := validateID("ServiceIDs", id); err != nil {
+			return nil, err
+		}
+	}
+
+	param := *p
+	if param.Interval <= 0 {
+		param.Interval = defaultSupervisorInterval
+	}
+	if param.Window <= 0 {
+		param.Window = defaultCrashLoopWindow
+	}
+	if param.Threshold <= 0 {
+		param.Threshold = defaultCrashLoopThreshold
+	}
+	if param.Backoff <= 0 {
+		param.Backoff = defaultRemediationBackoff
+	}
+	if param.MaxBackoff <= 0 {
+		param.MaxBackoff = defaultRemediationMaxDelay
+	}
+	if param.MaxRemediations <= 0 {
+		param.MaxRemediations = defaultMaxRemediations
+	}
+
+	states := map[string]*supervisedService{}
+	for _, id := range param.ServiceIDs {
+		states[id] = &supervisedService{backoff: param.Backoff}
+	}
+
+	return &Supervisor{
+		client: c,
+		param:  param,
+		now:    time.Now,
+		states: states,
+	}, nil
+}
+
+// Run watches services until ctx is done
+func (s *Supervisor) Run(ctx context.Context) error {
+	ticker := time.NewTicker(s.param.Interval)
+	defer ticker.Stop()
+
+	for {
+		s.Check(ctx)
+
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-ticker.C:
+		}
+	}
+}
+
+// Check reads all watched services once and remediates services in crash loop
+func (s *Supervisor) Check(ctx context.Context) {
+	for _, id := range s.param.ServiceIDs {
+		if ctx.Err() != nil {
+			return
+		}
+		service, err := s.client.ReadService(id)
+		if err == nil && (service.Data == nil || service.Data.Attributes == nil) {
+			err = fmt.Errorf("service %q has no attributes", id)
+		}
+		if err != nil {
+			if s.param.OnError != nil {
+				s.param.OnError(id, err)
+			}
+			continue
+		}
+
+		event := s.observe(id, service.Data)
+		if event == nil {
+			continue
+		}
+		s.remediate(ctx, event)
+		if s.param.OnCrashLoop != nil {
+			s.param.OnCrashLoop(event)
+		}
+	}
+}
+
+// observe updates state of the service, returns *CrashLoopEvent if crash loop is detected
+func (s *Supervisor) observe(id string, service *Service) *CrashLoopEvent {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	state := s.states[id]
+	now := s.now()
+
+	failedAt := service.Attributes.LastInstanceFailedAt
+	failed := false
+	if failedAt != nil {
+		// initial value is not a change, only remember it
+		if state.observed && (state.lastFailedAt == nil || !failedAt.Equal(*state.lastFailedAt)) {
+			state.failures = append(state.failures, *failedAt)
+			failed = true
+		}
+		state.lastFailedAt = failedAt
+	}
+	state.observed = true
+
+	var failures []time.Time
+	for _, f := range state.failures {
+		if now.Sub(f) <= s.param.Window {
+			failures = append(failures, f)
+		}
+	}
+	state.failures = failures
+
+	spec := service.ToRequestParam()
+	switch {
+	case state.candidate == nil || len(diffRequestParam(state.candidate, spec)) > 0:
+		state.candidate, state.candidateSince = spec, now
+	case failed:
+		state.candidateSince = now
+	case now.Sub(state.candidateSince) >= s.param.Window && len(state.failures) == 0:
+		state.knownGood = state.candidate
+		state.remediations = 0
+		state.backoff = s.param.Backoff
+	}
+
+	if len(state.failures) < s.param.Threshold {
+		return nil
+	}
+	// failures are reported once, next event requires Threshold new failures
+	failures, state.failures = state.failures, nil
+	return &CrashLoopEvent{
+		ServiceID:   id,
+		Failures:    failures,
+		LastFailure: service.FailureInfo(),
+		Remediation: s.param.Remediation,
+	}
+}
+
+// remediate applies remediation to the service with backoff
+func (s *Supervisor) remediate(ctx context.Context, event *CrashLoopEvent) {
+	if event.Remediation == RemediationAlert {
+		return
+	}
+
+	s.mu.Lock()
+	state := s.states[event.ServiceID]
+	now := s.now()
+	if state.remediations >= s.param.MaxRemediations || now.Before(state.nextAllowed) {
+		s.mu.Unlock()
+		event.Skipped = true
+		return
+	}
+	state.remediations++
+	state.nextAllowed = now.Add(state.backoff)
+	state.backoff *= 2
+	if state.backoff > s.param.MaxBackoff {
+		state.backoff = s.param.MaxBackoff
+	}
+	knownGood := state.knownGood
+	s.mu.Unlock()
+
+	switch event.Remediation {
+	case RemediationPowerCycle:
+		event.Err = s.powerCycle(ctx, event.ServiceID)
+	case RemediationRollback:
+		if knownGood == nil {
+			event.Err = errNoKnownGoodSpec
+			return
+		}
+		_, event.Err = s.client.UpdateService(event.ServiceID, knownGood)
+	}
+}
+
+func (s *Supervisor) powerCycle(ctx context.Context, serviceID string) error {
+	if err := s.client.PowerOff(serviceID); err != nil {
+		return err
+	}
+	if err := s.client.WaitForState(ctx, serviceID, StatusStopped); err != nil {
+		return err
+	}
+	return s.client.PowerOn(serviceID)
+}
+
+// KnownGoodSpec returns the last known-good spec of the service, returns nil if not observed yet
+func (s *Supervisor) KnownGoodSpec(serviceID string) *RequestParam {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if state, ok := s.states[serviceID]; ok {
+		return state.knownGood
+	}
+	return nil
+}
@@ -0,0 +1,193 @@
+package arukas
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+type supervisorTest struct {
+	client     *testClient
+	supervisor *Supervisor
+	now        time.Time
+	events     []*CrashLoopEvent
+}
+
+func newSupervisorTest(t *testing.T, remediation Remediation) *supervisorTest {
+	st := &supervisorTest{
+		client: newTestClient(),
+		now:    time.Date(2018, 3, 1, 0, 0, 0, 0, time.UTC),
+	}
+	st.client.addApp(testAppID, testServiceID, "foo", &ServiceAttr{
+		Image:     "nginx:1",
+		Instances: 1,
+		Status:    StatusRunning,
+	})
+
+	s, err := NewSupervisor(st.client, &SupervisorParam{
+		ServiceIDs:      []string{testServiceID},
+		Window:          10 * time.Minute,
+		Threshold:       3,
+		Remediation:     remediation,
+		Backoff:         time.Minute,
+		MaxRemediations: 2,
+		OnCrashLoop: func(event *CrashLoopEvent) {
+			st.events = append(st.events, event)
+		},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	s.now = func() time.Time { return st.now }
+	st.supervisor = s
+	return st
+}
+
+// tick advances clock and checks services
+func (st *supervisorTest) tick(d time.Duration) {
+	st.now = st.now.Add(d)
+	st.supervisor.Check(context.Background())
+}
+
+// fail records an instance failure at current time
+func (st *supervisorTest) fail() {
+	failedAt := st.now
+	st.client.updateAttr(testServiceID, func(attr *ServiceAttr) {
+		attr.LastInstanceFailedAt = &failedAt
+	})
+}
+
+func TestSupervisor_Detect(t *testing.T) {
+
+	t.Run("Failures within window", func(t *testing.T) {
+		st := newSupervisorTest(t, RemediationAlert)
+		st.tick(0)
+		for i := 0; i < 3; i++ {
+			st.fail()
+			st.tick(time.Minute)
+		}
+
+		assert.Len(t, st.events, 1)
+		assert.Len(t, st.events[0].Failures, 3)
+		assert.Equal(t, testServiceID, st.events[0].ServiceID)
+	})
+
+	t.Run("Failures outside window", func(t *testing.T) {
+		st := newSupervisorTest(t, RemediationAlert)
+		st.tick(0)
+		for i := 0; i < 3; i++ {
+			st.fail()
+			st.tick(6 * time.Minute)
+		}
+
+		assert.Empty(t, st.events)
+	})
+
+	t.Run("Initial failure is not counted", func(t *testing.T) {
+		st := newSupervisorTest(t, RemediationAlert)
+		st.fail()
+		st.tick(0)
+		st.fail()
+		st.tick(time.Minute)
+		st.fail()
+		st.tick(time.Minute)
+
+		assert.Empty(t, st.events)
+	})
+
+	t.Run("Service without attributes", func(t *testing.T) {
+		st := newSupervisorTest(t, RemediationAlert)
+		var errs []error
+		st.supervisor.param.OnError = func(serviceID string, err error) {
+			errs = append(errs, err)
+		}
+		st.client.services[testServiceID].Attributes = nil
+
+		assert.NotPanics(t, func() { st.tick(0) })
+		assert.Len(t, errs, 1)
+		assert.Empty(t, st.events)
+	})
+}
+
+func TestSupervisor_Remediate(t *testing.T) {
+
+	crashLoop := func(st *supervisorTest) {
+		for i := 0; i < 3; i++ {
+			st.fail()
+			st.tick(10 * time.Second)
+		}
+	}
+
+	t.Run("Power cycle with backoff", func(t *testing.T) {
+		st := newSupervisorTest(t, RemediationPowerCycle)
+		st.tick(0)
+
+		crashLoop(st)
+		assert.Len(t, st.events, 1)
+		assert.False(t, st.events[0].Skipped)
+		assert.NoError(t, st.events[0].Err)
+		assert.Equal(t, 1, st.client.called("PowerOff"))
+		assert.Equal(t, 1, st.client.called("PowerOn"))
+
+		// within backoff(1 minute)
+		crashLoop(st)
+		assert.Len(t, st.events, 2)
+		assert.True(t, st.events[1].Skipped)
+
+		// after backoff, but MaxRemediations(2) is not reached
+		st.tick(2 * time.Minute)
+		crashLoop(st)
+		assert.False(t, st.events[len(st.events)-1].Skipped)
+		assert.Equal(t, 2, st.client.called("PowerOn"))
+
+		// MaxRemediations is reached
+		st.tick(5 * time.Minute)
+		crashLoop(st)
+		assert.True(t, st.events[len(st.events)-1].Skipped)
+		assert.Equal(t, 2, st.client.called("PowerOn"))
+	})
+
+	t.Run("Rollback to known-good spec", func(t *testing.T) {
+		st := newSupervisorTest(t, RemediationRollback)
+		st.tick(0)
+		st.tick(11 * time.Minute)
+		assert.Equal(t, "nginx:1", st.supervisor.KnownGoodSpec(testServiceID).Image)
+
+		// bad change
+		st.client.updateAttr(testServiceID, func(attr *ServiceAttr) {
+			attr.Image = "nginx:2"
+		})
+		st.tick(time.Minute)
+		crashLoop(st)
+
+		assert.Len(t, st.events, 1)
+		assert.NoError(t, st.events[0].Err)
+		s, err := st.client.ReadService(testServiceID)
+		assert.NoError(t, err)
+		assert.Equal(t, "nginx:1", s.Image())
+	})
+
+	t.Run("Rollback without known-good spec", func(t *testing.T) {
+		st := newSupervisorTest(t, RemediationRollback)
+		st.tick(0)
+		crashLoop(st)
+
+		assert.Len(t, st.events, 1)
+		assert.Equal(t, errNoKnownGoodSpec, st.events[0].Err)
+		assert.Equal(t, 0, st.client.called("UpdateService"))
+	})
+
+	t.Run("Remediation error", func(t *testing.T) {
+		st := newSupervisorTest(t, RemediationPowerCycle)
+		st.client.errors["PowerOff"] = errors.New("dummy")
+		st.tick(0)
+		crashLoop(st)
+
+		assert.Len(t, st.events, 1)
+		assert.Error(t, st.events[0].Err)
+		assert.Equal(t, 0, st.client.called("PowerOn"))
+	})
+}
@@ -0,0 +1,250 @@
+package arukas
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+)
+
+// errTxAlreadyRun is returned when Tx.Run is called twice
+var errTxAlreadyRun = errors.New("transaction has already been run")
+
+// TxService represents a service operated in a Tx.
+// ID of the service created by Tx.CreateApp is set when the step is performed.
+type TxService struct {
+	appID string
+	id    string
+}
+
+// ID returns the service ID, returns empty string if the service isn't created yet
+func (s *TxService) ID() string {
+	return s.id
+}
+
+// AppID returns the app ID, returns empty string if the app isn't known
+func (s *TxService) AppID() string {
+	return s.appID
+}
+
+// TxStepResult represents result of a step or a compensation
+type TxStepResult struct {
+	Step string
+	Err  error
+}
+
+// TxError represents an error of a step and results of compensations
+type TxError struct {
+	// Step is name of the failed step
+	Step string
+	Err  error
+	// Rollback is results of compensations, in the order performed
+	Rollback []*TxStepResult
+}
+
+// Error implements error interface
+func (e *TxError) Error() string {
+	msg := fmt.Sprintf("step %q failed: %s", e.Step, e.Err)
+	var failed []string
+	for _, r := range e.Rollback {
+		if r.Err != nil {
+			failed = append(failed, fmt.Sprintf("%s: %s", r.Step, r.Err))
+		}
+	}
+	if len(failed) == 0 {
+		return fmt.Sprintf("%s, rolled back %d step(s)", msg, len(e.Rollback))
+	}
+	return fmt.Sprintf("%s, rollback failed: [%s]", msg, strings.Join(failed, ", "))
+}
+
+// RolledBack returns true if all compensations are succeeded
+func (e *TxError) RolledBack() bool {
+	for _, r := range e.Rollback {
+		if r.Err != nil {
+			return false
+		}
+	}
+	return true
+}
+
+// txStep represents a step and its compensation.
+// compensate is set by action when the step is performed, nil means nothing to compensate.
+type txStep struct {
+	name   string
+	action func(ctx context.Context) (compensate func(ctx context.Context) error, err error)
+}
+
+// Tx records multi-step operations, and performs them as a saga.
+// If a step fails, compensations of performed steps are run in reverse order.
+type Tx struct {
+	client Client
+	steps  []*txStep
+	done   bool
+}
+
+// NewTx returns new Tx
+func NewTx(c Client) *Tx {
+	return &Tx{client: c}
+}
+
+// Service returns *TxService of existing service
+func (tx *Tx) Service(id string) *TxService {
+	return &TxService{id: id}
+}
+
+// Do adds a custom step. compensate may be nil.
+func (tx *Tx) Do(name string, action func(ctx context.Context) error, compensate func(ctx context.Context) error) *Tx {
+	tx.add(name, func(ctx context.Context) (func(ctx context.Context) error, error) {
+		if err := action(ctx); err != nil {
+			return nil, err
+		}
+		return compensate, nil
+	})
+	return tx
+}
+
+// CreateApp adds a step to create app. The app is deleted on rollback.
+func (tx *Tx) CreateApp(p *RequestParam) *TxService {
+	s := &TxService{}
+	tx.add("CreateApp", func(ctx context.Context) (func(ctx context.Context) error, error) {
+		app, err := tx.client.CreateApp(p)
+		if err != nil {
+			return nil, err
+		}
+		s.appID = app.Data.ID
+		if service := app.Service(); service != nil {
+			s.id = service.ID
+		}
+		return func(ctx context.Context) error {
+			return tx.client.DeleteApp(s.appID)
+		}, nil
+	})
+	return s
+}
+
+// UpdateService adds a step to update the service. Previous spec is restored on rollback.
+func (tx *Tx) UpdateService(s *TxService, p *RequestParam) *Tx {
+	tx.add("UpdateService", func(ctx context.Context) (func(ctx context.Context) error, error) {
+		restore, err := tx.restoreSpec(s)
+		if err != nil {
+			return nil, err
+		}
+		if _, err := tx.client.UpdateService(s.id, p); err != nil {
+			return nil, err
+		}
+		return restore, nil
+	})
+	return tx
+}
+
+// PatchService adds a step to patch the service. Previous spec is restored on rollback.
+func (tx *Tx) PatchService(s *TxService, patch *ServicePatch) *Tx {
+	tx.add("PatchService", func(ctx context.Context) (func(ctx context.Context) error, error) {
+		restore, err := tx.restoreSpec(s)
+		if err != nil {
+			return nil, err
+		}
+		if _, err := tx.client.PatchService(ctx, s.id, patch); err != nil {
+			return nil, err
+		}
+		return restore, nil
+	})
+	return tx
+}
+
+// PowerOn adds a step to power on the service. The service is powered off on rollback if it was not running.
+func (tx *Tx) PowerOn(s *TxService) *Tx {
+	tx.add("PowerOn", tx.power(s, true))
+	return tx
+}
+
+// PowerOff adds a step to power off the service. The service is powered on on rollback if it was running.
+func (tx *Tx) PowerOff(s *TxService) *Tx {
+	tx.add("PowerOff", tx.power(s, false))
+	return tx
+}
+
+func (tx *Tx) power(s *TxService, on bool) func(ctx context.Context) (func(ctx context.Context) error, error) {
+	return func(ctx context.Context) (func(ctx context.Context) error, error) {
+		current, err := tx.client.ReadService(s.id)
+		if err != nil {
+			return nil, err
+		}
+		wasRunning := current.Status() == StatusRunning
+		if on == wasRunning {
+			return nil, nil
+		}
+
+		if on {
+			if err := tx.client.PowerOn(s.id); err != nil {
+				return nil, err
+			}
+			return func(ctx context.Context) error {
+				return tx.client.PowerOff(s.id)
+			}, nil
+		}
+		if err := tx.client.PowerOff(s.id); err != nil {
+			return nil, err
+		}
+		return func(ctx context.Context) error {
+			return tx.client.PowerOn(s.id)
+		}, nil
+	}
+}
+
+// restoreSpec returns a compensation which restores current spec of the service
+func (tx *Tx) restoreSpec(s *TxService) (func(ctx context.Context) error, error) {
+	current, err := tx.client.ReadService(s.id)
+	if err != nil {
+		return nil, err
+	}
+	previous := current.Data.ToRequestParam()
+	return func(ctx context.Context) error {
+		_, err := tx.client.UpdateService(s.id, previous)
+		return err
+	}, nil
+}
+
+func (tx *Tx) add(name string, action func(ctx context.Context) (func(ctx context.Context) error, error)) {
+	tx.steps = append(tx.steps, &txStep{name: fmt.Sprintf("%d:%s", len(tx.steps)+1, name), action: action})
+}
+
+// Run performs steps in order. If a step fails or ctx is done, compensations of performed steps
+// are run in reverse order and *TxError is returned. Compensations are run even if ctx is done.
+// Tx can be run only once.
+func (tx *Tx) Run(ctx context.Context) error {
+	if tx.done {
+		return errTxAlreadyRun
+	}
+	tx.done = true
+
+	type performed struct {
+		name       string
+		compensate func(ctx context.Context) error
+	}
+	var stack []performed
+
+	for _, step := range tx.steps {
+		err := ctx.Err()
+		if err == nil {
+			var compensate func(ctx context.Context) error
+			compensate, err = step.action(ctx)
+			if err == nil {
+				if compensate != nil {
+					stack = append(stack, performed{name: step.name, compensate: compensate})
+				}
+				continue
+			}
+		}
+
+		txErr := &TxError{Step: step.name, Err: err}
+		for i := len(stack) - 1; i >= 0; i-- {
+			txErr.Rollback = append(txErr.Rollback, &TxStepResult{
+				Step: stack[i].name,
+				Err:  stack[i].compensate(context.Background()),
+			})
+		}
+		return txErr
+	}
+	return nil
+}
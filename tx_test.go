@@ -0,0 +1,112 @@
+package arukas
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestTx(t *testing.T) {
+	ctx := context.Background()
+
+	t.Run("Succeeded", func(t *testing.T) {
+		c := newTestClient()
+		tx := NewTx(c)
+		s := tx.CreateApp(testRequestParam("foo"))
+		tx.PowerOn(s)
+		tx.PatchService(s, &ServicePatch{CustomDomains: []string{"www.example.com"}})
+
+		assert.NoError(t, tx.Run(ctx))
+		assert.NotEmpty(t, s.ID())
+		res, _ := c.ReadService(s.ID())
+		assert.Equal(t, StatusRunning, res.Status())
+		assert.Len(t, res.Data.Attributes.CustomDomains, 1)
+
+		assert.Equal(t, errTxAlreadyRun, tx.Run(ctx))
+	})
+
+	t.Run("Rollback", func(t *testing.T) {
+		c := newTestClient()
+		c.errors["PatchService"] = errors.New("dummy")
+
+		tx := NewTx(c)
+		s := tx.CreateApp(testRequestParam("foo"))
+		tx.PowerOn(s)
+		tx.PatchService(s, &ServicePatch{CustomDomains: []string{"www.example.com"}})
+
+		err := tx.Run(ctx)
+		assert.Error(t, err)
+		txErr, ok := err.(*TxError)
+		assert.True(t, ok)
+		assert.Equal(t, "3:PatchService", txErr.Step)
+		assert.True(t, txErr.RolledBack())
+		assert.Equal(t, []*TxStepResult{
+			{Step: "2:PowerOn"},
+			{Step: "1:CreateApp"},
+		}, txErr.Rollback)
+		assert.Equal(t, 1, c.called("PowerOff"))
+		assert.Equal(t, 1, c.called("DeleteApp"))
+
+		apps, _ := c.ListApps()
+		assert.Empty(t, apps.Data)
+	})
+
+	t.Run("Restore previous spec", func(t *testing.T) {
+		c := newTestClient()
+		c.addApp(testAppID, testServiceID, "foo", &ServiceAttr{Image: "nginx:1", Instances: 1, Status: StatusRunning})
+
+		tx := NewTx(c)
+		s := tx.Service(testServiceID)
+		tx.UpdateService(s, &RequestParam{Image: "nginx:2", Instances: 1, Ports: Ports{{Number: 80, Protocol: "tcp"}}})
+		// already running, nothing to compensate
+		tx.PowerOn(s)
+		tx.Do("custom", func(ctx context.Context) error {
+			return errors.New("dummy")
+		}, nil)
+
+		err := tx.Run(ctx)
+		assert.Error(t, err)
+		assert.Len(t, err.(*TxError).Rollback, 1)
+		assert.Equal(t, 0, c.called("PowerOn"))
+
+		res, _ := c.ReadService(testServiceID)
+		assert.Equal(t, "nginx:1", res.Image())
+	})
+
+	t.Run("Compensation failed", func(t *testing.T) {
+		c := newTestClient()
+		c.errors["DeleteApp"] = errors.New("dummy")
+		c.errors["PowerOn"] = errors.New("dummy")
+
+		tx := NewTx(c)
+		tx.PowerOn(tx.CreateApp(testRequestParam("foo")))
+
+		err := tx.Run(ctx)
+		assert.Error(t, err)
+		assert.False(t, err.(*TxError).RolledBack())
+		assert.Contains(t, err.Error(), "rollback failed: [1:CreateApp: dummy]")
+	})
+
+	t.Run("Canceled", func(t *testing.T) {
+		c := newTestClient()
+		ctx, cancel := context.WithCancel(context.Background())
+
+		tx := NewTx(c)
+		tx.CreateApp(testRequestParam("foo"))
+		tx.Do("cancel", func(ctx context.Context) error {
+			cancel()
+			return nil
+		}, nil)
+		tx.Do("never", func(ctx context.Context) error {
+			t.Fatal("never called")
+			return nil
+		}, nil)
+
+		err := tx.Run(ctx)
+		assert.Error(t, err)
+		assert.Equal(t, context.Canceled, err.(*TxError).Err)
+		assert.Equal(t, 1, c.called("DeleteApp"))
+	})
+}
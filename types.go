@@ -47,87 +47,151 @@ type Port struct {
 	Number int32 `json:"number"`
 }
 
+// String returns port in "<number>/<protocol>" format. e.g. "80/tcp"
+func (p *Port) String() string {
+	return fmt.Sprintf("%d/%s", p.Number, p.Protocol)
+}
+
 // MarshalJSON implements json.Marshaler
 func (p *Port) MarshalJSON() ([]byte, error) {
-	return json.Marshal(fmt.Sprintf("%d/%s", p.Number, p.Protocol))
+	return json.Marshal(p.String())
 }
 
 // Ports is a slice of Ports. A service can have multiple ports.
 type Ports []*Port
-type oldPortFormat Ports
-type newPortFormat []string
-
-func (pf newPortFormat) toPorts() (Ports, error) {
-	ports := make(Ports, 0)
-	for _, p := range pf {
-		var (
-			parsedPort *Port
-			err        error
-		)
-		if parsedPort, err = parseNewPortFormat(p); err != nil {
-			return nil, err
-		}
 
-		ports = append(ports, parsedPort)
+// ParsePort parses port string in "<number>[/<protocol>]" format. e.g. "80/tcp", "53/udp"
+// If protocol is omitted, "tcp" is used.
+// Number must be in range [1 - 65535], protocol must be either tcp/udp.
+func ParsePort(str string) (*Port, error) {
+	ports, err := parsePortRange(str)
+	if err != nil {
+		return nil, err
 	}
-
-	return ports, nil
+	if len(ports) != 1 {
+		return nil, fmt.Errorf("port %q is a range, use ParsePorts instead", str)
+	}
+	return ports[0], nil
 }
 
-func (pf oldPortFormat) toPorts() (Ports, error) {
-	ports := make(Ports, 0)
-	for _, p := range pf {
-		ports = append(ports,
-			&Port{
-				Protocol: p.Protocol,
-				Number:   p.Number,
-			},
-		)
+// maxPorts is the max number of ports per service
+const maxPorts = 20
+
+// ParsePorts parses port strings. Each string accepts ParsePort format
+// and range format "<from>-<to>[/<protocol>]" which expands to multiple ports. e.g. "8000-8010/tcp"
+// A range can't have more ports than a service can have(20).
+func ParsePorts(strs ...string) (Ports, error) {
+	ports := Ports{}
+	for _, str := range strs {
+		parsed, err := parsePortRange(str)
+		if err != nil {
+			return nil, err
+		}
+		ports = append(ports, parsed...)
 	}
 	return ports, nil
 }
 
-func parseNewPortFormat(str string) (*Port, error) {
-	var protocol string
-	var parsedInt int64
-	var number int32
-	var err error
+func parsePortRange(str string) (Ports, error) {
+	protocol := "tcp"
 	splitted := strings.Split(str, "/")
-	if len(splitted) <= 1 {
-		protocol = "tcp"
-	} else {
+	switch len(splitted) {
+	case 1:
+	case 2:
 		protocol = splitted[1]
+	default:
+		return nil, fmt.Errorf("port %q is malformed", str)
+	}
+	if err := validatePortProtocol(str, protocol); err != nil {
+		return nil, err
 	}
 
-	if parsedInt, err = strconv.ParseInt(splitted[0], 10, 32); err != nil {
+	numbers := strings.Split(splitted[0], "-")
+	if len(numbers) > 2 {
+		return nil, fmt.Errorf("port %q is malformed", str)
+	}
+	from, err := parsePortNumber(str, numbers[0])
+	if err != nil {
 		return nil, err
 	}
-	number = int32(parsedInt)
+	to := from
+	if len(numbers) == 2 {
+		if to, err = parsePortNumber(str, numbers[1]); err != nil {
+			return nil, err
+		}
+		if to < from {
+			return nil, fmt.Errorf("port %q has invalid range", str)
+		}
+		if to-from+1 > maxPorts {
+			return nil, fmt.Errorf("port %q has more than %d ports", str, maxPorts)
+		}
+	}
+
+	ports := make(Ports, 0, to-from+1)
+	for n := from; n <= to; n++ {
+		ports = append(ports, &Port{Protocol: protocol, Number: n})
+	}
+	return ports, nil
+}
+
+func parsePortNumber(str, number string) (int32, error) {
+	// ParseUint rejects signs such as "+80" and "-80"
+	parsed, err := strconv.ParseUint(number, 10, 32)
+	if err != nil || parsed < 1 || parsed > 65535 {
+		return 0, fmt.Errorf("port %q must have number between 1 and 65535", str)
+	}
+	return int32(parsed), nil
+}
 
-	return &Port{Protocol: protocol, Number: number}, nil
+func validatePortProtocol(str, protocol string) error {
+	for _, p := range ValidProtocols {
+		if protocol == p {
+			return nil
+		}
+	}
+	return fmt.Errorf("port %q must have protocol in [%s]", str, strings.Join(ValidProtocols, "/"))
 }
 
 // UnmarshalJSON parses ports in both old and new port format and convert them to Port.
+// Old format is a list of objects such as {"protocol": "tcp", "number": 80},
+// new format is a list of strings such as "80/tcp".
 func (ports *Ports) UnmarshalJSON(data []byte) error {
-	op := new(oldPortFormat)
-	np := new(newPortFormat)
-	var err error
-	if err = json.Unmarshal(data, op); err != nil {
-		// Attempt parse as new format
-		if err = json.Unmarshal(data, np); err != nil {
-			return err
-		}
-		if *ports, err = np.toPorts(); err != nil {
-			return err
-		}
-	} else {
-		if *ports, err = op.toPorts(); err != nil {
-			return err
+	var elements []json.RawMessage
+	if err := json.Unmarshal(data, &elements); err != nil {
+		return err
+	}
+
+	parsed := make(Ports, 0, len(elements))
+	for i, e := range elements {
+		port, err := unmarshalPort(e)
+		if err != nil {
+			return fmt.Errorf("ports[%d]: %s", i, err)
 		}
+		parsed = append(parsed, port)
 	}
+	*ports = parsed
 	return nil
 }
 
+func unmarshalPort(data json.RawMessage) (*Port, error) {
+	var str string
+	if err := json.Unmarshal(data, &str); err == nil {
+		return ParsePort(str)
+	}
+
+	var old struct {
+		Protocol string `json:"protocol"`
+		Number   int32  `json:"number"`
+	}
+	if err := json.Unmarshal(data, &old); err != nil {
+		return nil, err
+	}
+	if old.Protocol == "" {
+		old.Protocol = "tcp"
+	}
+	return ParsePort(fmt.Sprintf("%d/%s", old.Number, old.Protocol))
+}
+
 // ValidProtocols is a list of valid protocol
 var ValidProtocols = []string{"tcp", "udp"}
 
@@ -158,3 +222,17 @@ type Env struct {
 	Key   string `json:"key"`
 	Value string `json:"value"`
 }
+
+// secretEnvKeys are substrings of environment variable keys which hold secrets
+var secretEnvKeys = []string{"PASSWORD", "PASSWD", "SECRET", "TOKEN", "KEY", "CREDENTIAL", "PRIVATE"}
+
+// isSecretEnvKey returns true if key contains one of patterns, case-insensitive
+func isSecretEnvKey(key string, patterns []string) bool {
+	key = strings.ToUpper(key)
+	for _, p := range patterns {
+		if strings.Contains(key, strings.ToUpper(p)) {
+			return true
+		}
+	}
+	return false
+}
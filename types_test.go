@@ -0,0 +1,79 @@
+package arukas
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestParsePort(t *testing.T) {
+
+	expects := []struct {
+		input  string
+		expect *Port
+	}{
+		{input: "80", expect: &Port{Protocol: "tcp", Number: 80}},
+		{input: "80/tcp", expect: &Port{Protocol: "tcp", Number: 80}},
+		{input: "53/udp", expect: &Port{Protocol: "udp", Number: 53}},
+		{input: "65535/tcp", expect: &Port{Protocol: "tcp", Number: 65535}},
+		{input: "80/sctp"},
+		{input: "80/tcp/extra"},
+		{input: "-80/tcp"},
+		{input: "+80/tcp"},
+		{input: "0/tcp"},
+		{input: "65536/tcp"},
+		{input: "foo/tcp"},
+		{input: ""},
+		{input: "8000-8010/tcp"},
+	}
+
+	for _, expect := range expects {
+		t.Run(expect.input, func(t *testing.T) {
+			port, err := ParsePort(expect.input)
+			if expect.expect == nil {
+				assert.Error(t, err)
+				return
+			}
+			assert.NoError(t, err)
+			assert.Equal(t, expect.expect, port)
+		})
+	}
+}
+
+func TestParsePorts(t *testing.T) {
+	t.Run("Range", func(t *testing.T) {
+		ports, err := ParsePorts("80", "8000-8002/udp")
+		assert.NoError(t, err)
+		assert.Equal(t, Ports{
+			{Protocol: "tcp", Number: 80},
+			{Protocol: "udp", Number: 8000},
+			{Protocol: "udp", Number: 8001},
+			{Protocol: "udp", Number: 8002},
+		}, ports)
+	})
+
+	t.Run("Invalid range", func(t *testing.T) {
+		for _, input := range []string{"8010-8000/tcp", "8000-8010-8020/tcp", "8000-/tcp", "8000-65536/tcp", "1-65535", "8000-8020/tcp"} {
+			_, err := ParsePorts(input)
+			assert.Error(t, err, input)
+		}
+	})
+
+	t.Run("Range is limited to max ports", func(t *testing.T) {
+		ports, err := ParsePorts("8000-8019")
+		assert.NoError(t, err)
+		assert.Len(t, ports, 20)
+
+		_, err = ParsePorts("1-65535")
+		assert.Error(t, err)
+		assert.Contains(t, err.Error(), `"1-65535"`)
+	})
+
+	t.Run("Round-trip", func(t *testing.T) {
+		for _, input := range []string{"80/tcp", "34197/udp"} {
+			port, err := ParsePort(input)
+			assert.NoError(t, err)
+			assert.Equal(t, input, port.String())
+		}
+	})
+}
@@ -2,14 +2,73 @@ package arukas
 
 import (
 	"fmt"
+	"regexp"
+	"strconv"
 	"strings"
 
 	"reflect"
 	"time"
 
 	"github.com/google/uuid"
+	"github.com/hashicorp/go-multierror"
 )
 
+// ValidationError represents an error of parameter validation
+type ValidationError struct {
+	// Field is a path of invalid field. e.g. "Ports[2].Number"
+	Field string
+	// Reason describes why the field is invalid
+	Reason string
+}
+
+// Error implements error interface
+func (e *ValidationError) Error() string {
+	return fmt.Sprintf("%q %s", e.Field, e.Reason)
+}
+
+// newValidationError returns new *ValidationError
+func newValidationError(label, format string, args ...interface{}) error {
+	return &ValidationError{
+		Field:  label,
+		Reason: fmt.Sprintf(format, args...),
+	}
+}
+
+// ValidationErrors returns all *ValidationError contained in err. Other errors are ignored.
+func ValidationErrors(err error) []*ValidationError {
+	var errs []*ValidationError
+	for _, e := range flattenErrors(err) {
+		if ve, ok := e.(*ValidationError); ok {
+			errs = append(errs, ve)
+		}
+	}
+	return errs
+}
+
+// flattenErrors returns errors contained in err, nested *multierror.Error are expanded
+func flattenErrors(err error) []error {
+	var errs []error
+	switch e := err.(type) {
+	case nil:
+	case *multierror.Error:
+		for _, inner := range e.Errors {
+			errs = append(errs, flattenErrors(inner)...)
+		}
+	default:
+		errs = append(errs, e)
+	}
+	return errs
+}
+
+// fieldPath returns path of the field in slice. e.g. "Ports[2].Number"
+func fieldPath(label string, index int, field string) string {
+	path := fmt.Sprintf("%s[%d]", label, index)
+	if field != "" {
+		path += "." + field
+	}
+	return path
+}
+
 // validateID validates id formats. id needs UUID format.
 func validateID(label, value string) error {
 	if value == "" {
@@ -24,7 +83,7 @@ func validateID(label, value string) error {
 
 // malformatUUIDError returns an error indicating that the ID format is illegal as a UUID
 func malformatUUIDError(label string) error {
-	return fmt.Errorf("%q is malformated as UUID", label)
+	return newValidationError(label, "is malformated as UUID")
 }
 
 // validateRequired validates value is not empty
@@ -37,7 +96,12 @@ func validateRequired(label string, value interface{}) error {
 
 // requiredError returns an error indicating that value is required
 func requiredError(label string) error {
-	return fmt.Errorf("%q is required", label)
+	return newValidationError(label, "is required")
+}
+
+// duplicatedError returns an error indicating that value is duplicated
+func duplicatedError(label string) error {
+	return newValidationError(label, "is duplicated")
 }
 
 // validateRange validates value is in range of min and max
@@ -50,7 +114,7 @@ func validateRange(label string, value, min, max int) error {
 
 // outOfRangeError returns an error indicating that value is in out of range
 func outOfRangeError(label string, min, max int) error {
-	return fmt.Errorf("%q must be between %d and %d", label, min, max)
+	return newValidationError(label, "must be between %d and %d", min, max)
 }
 
 // valiateStrByteLen validates length of value's bytes is in range of min and max
@@ -64,7 +128,7 @@ func valiateStrByteLen(label, value string, min, max int) error {
 
 // strByteLenError returns an error indicating that length of value's bytes is in out of range
 func strByteLenError(label string, min, max int) error {
-	return fmt.Errorf("%q must be between %d and %d bytes", label, min, max)
+	return newValidationError(label, "must be between %d and %d bytes", min, max)
 }
 
 // validateInStrValues validates value is exists in specified values
@@ -79,7 +143,97 @@ func validateInStrValues(label, value string, values ...string) error {
 
 // inStrValuesError return an error indicating that value is exists in specified values
 func inStrValuesError(label string, values ...string) error {
-	return fmt.Errorf("%q mest be in [%s]", label, strings.Join(values, "/"))
+	return newValidationError(label, "must be in [%s]", strings.Join(values, "/"))
+}
+
+var (
+	// dnsLabelPattern matches a DNS label(RFC 1123)
+	dnsLabelPattern = regexp.MustCompile(`^[a-zA-Z0-9]([a-zA-Z0-9-]{0,61}[a-zA-Z0-9])?$`)
+	// imageReferencePattern matches a Docker image reference.
+	// ref: https://github.com/docker/distribution/blob/master/reference/reference.go
+	imageReferencePattern = regexp.MustCompile(
+		`^(?:(?:[a-zA-Z0-9]|[a-zA-Z0-9][a-zA-Z0-9-]*[a-zA-Z0-9])(?:\.(?:[a-zA-Z0-9]|[a-zA-Z0-9][a-zA-Z0-9-]*[a-zA-Z0-9]))*(?::[0-9]+)?/)?` + // domain
+			`[a-z0-9]+(?:(?:[._]|__|[-]*)[a-z0-9]+)*(?:/[a-z0-9]+(?:(?:[._]|__|[-]*)[a-z0-9]+)*)*` + // name
+			`(?::[\w][\w.-]{0,127})?` + // tag
+			`(?:@[A-Za-z][A-Za-z0-9]*(?:[-_+.][A-Za-z][A-Za-z0-9]*)*:[0-9a-fA-F]{32,})?$`, // digest
+	)
+)
+
+const maxImageNameLen = 255
+
+// appNamePattern matches a valid app name.
+// The API doesn't document rules of app names, this is a conservative rule of this library.
+// It is applied only to AppPatch and AppSpec, RequestParam accepts any non-empty name for compatibility.
+var appNamePattern = regexp.MustCompile(`^[a-zA-Z0-9][a-zA-Z0-9_.-]*$`)
+
+const maxAppNameLen = 63
+
+// validateAppName validates value is a valid app name
+func validateAppName(label, value string) error {
+	if err := validateRequired(label, value); err != nil {
+		return err
+	}
+	if err := valiateStrByteLen(label, value, 1, maxAppNameLen); err != nil {
+		return err
+	}
+	if !appNamePattern.MatchString(value) {
+		return newValidationError(label, "must start with alphanumeric and contain only alphanumerics, '-', '_' and '.'")
+	}
+	return nil
+}
+
+// appNameConflictError returns an error indicating that name is already used by other app
+func appNameConflictError(label, appID string) error {
+	return newValidationError(label, "is already used by app %s", appID)
+}
+
+// validateDNSLabel validates value is a valid DNS label
+func validateDNSLabel(label, value string) error {
+	if !dnsLabelPattern.MatchString(value) {
+		return newValidationError(label, "must be a valid DNS label")
+	}
+	return nil
+}
+
+// validateFQDN validates value is a valid fully qualified domain name
+func validateFQDN(label, value string) error {
+	labels := strings.Split(value, ".")
+	if len(value) > 253 || len(labels) < 2 {
+		return newValidationError(label, "must be a valid FQDN")
+	}
+	for _, l := range labels {
+		if !dnsLabelPattern.MatchString(l) {
+			return newValidationError(label, "must be a valid FQDN")
+		}
+	}
+	// top-level domain must not be all-numeric
+	if _, err := strconv.Atoi(labels[len(labels)-1]); err == nil {
+		return newValidationError(label, "must be a valid FQDN")
+	}
+	return nil
+}
+
+// validateImageReference validates value is a valid Docker image reference
+func validateImageReference(label, value string) error {
+	name := value
+	if i := strings.Index(name, "@"); i >= 0 {
+		name = name[:i]
+	}
+	if i := strings.LastIndex(name, ":"); i > strings.LastIndex(name, "/") {
+		name = name[:i]
+	}
+	if len(name) > maxImageNameLen || !imageReferencePattern.MatchString(value) {
+		return newValidationError(label, "must be a valid Docker image reference")
+	}
+	return nil
+}
+
+// validateNoNUL validates value doesn't contain NUL character
+func validateNoNUL(label, value string) error {
+	if strings.ContainsRune(value, 0) {
+		return newValidationError(label, "must not contain NUL character")
+	}
+	return nil
 }
 
 var numericZeros = []interface{}{
@@ -143,3 +297,11 @@ func isEmpty(object interface{}) bool {
 	}
 	return false
 }
+
+// validateNoServiceRef validates value has no unresolved service reference such as "${service:db.url}"
+func validateNoServiceRef(label, value string) error {
+	if ref := serviceRefPattern.FindString(value); ref != "" {
+		return newValidationError(label, "contains unresolved service reference %s, resolve it with ResolveServiceRefs or Linker", ref)
+	}
+	return nil
+}